@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charlesbases/protoc-gen-swagger/diagnostic"
+	"github.com/charlesbases/protoc-gen-swagger/diff"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+const usage = `usage:
+  protoc-gen-swagger breaking <previous> <current>     检查不兼容变更, 存在时退出码为 1
+  protoc-gen-swagger changelog <previous> <current>    输出 Markdown 格式的变更日志`
+
+// command 子命令. e.g. protoc-gen-swagger breaking swagger/v1.json swagger/api.json
+func command(args []string) {
+	if len(args) != 3 {
+		exit(usage)
+	}
+
+	switch args[0] {
+	case "breaking":
+		var d = compare(args[1], args[2])
+		if changes := d.Breaking(); len(changes) != 0 {
+			fmt.Printf("%d breaking changes\n\n%s", len(changes), d.Report(changes))
+			os.Exit(1)
+		}
+		fmt.Println("no breaking changes")
+	case "changelog":
+		fmt.Print(compare(args[1], args[2]).Changelog())
+	default:
+		exit(usage)
+	}
+}
+
+// compare 比较两个文档文件
+func compare(previous, current string) *diff.Diff {
+	old, err := diff.Load(previous)
+	if err != nil {
+		exit(err)
+	}
+	new, err := diff.Load(current)
+	if err != nil {
+		exit(err)
+	}
+	return diff.Compare(old, new)
+}
+
+// exit 参数或文档错误, 退出码为 2
+func exit(v interface{}) {
+	fmt.Fprintln(os.Stderr, v)
+	os.Exit(2)
+}
+
+// generated 比较上一版本文档与生成的文档. 文档解析失败时返回 nil
+func generated(previous string, file *pluginpb.CodeGeneratorResponse_File) *diff.Diff {
+	var position = diagnostic.Position{File: previous}
+
+	old, err := diff.Load(previous)
+	if err != nil {
+		diagnostic.Errorf(position, "%v", err)
+		return nil
+	}
+	new, err := diff.Parse([]byte(file.GetContent()))
+	if err != nil {
+		diagnostic.Errorf(position, "parse %s failed. %v", file.GetName(), err)
+		return nil
+	}
+	return diff.Compare(old, new)
+}
+
+// breaking 存在不兼容变更时生成失败
+func breaking(previous string, file *pluginpb.CodeGeneratorResponse_File) {
+	var d = generated(previous, file)
+	if d == nil {
+		return
+	}
+	if changes := d.Breaking(); len(changes) != 0 {
+		diagnostic.Errorf(diagnostic.Position{File: previous}, "%d breaking changes against %s\n\n%s", len(changes), file.GetName(), d.Report(changes))
+	}
+}
+
+// changelog 变更日志文件. e.g. pb.json => pb.changelog.md
+func changelog(previous string, file *pluginpb.CodeGeneratorResponse_File) *pluginpb.CodeGeneratorResponse_File {
+	var d = generated(previous, file)
+	if d == nil {
+		return nil
+	}
+
+	var name = strings.TrimSuffix(file.GetName(), filepath.Ext(file.GetName())) + ".changelog.md"
+	var content = d.Changelog()
+	return &pluginpb.CodeGeneratorResponse_File{
+		Name:    &name,
+		Content: &content,
+	}
+}
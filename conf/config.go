@@ -1,22 +1,168 @@
 package conf
 
 import (
+	"errors"
+	"fmt"
 	"path/filepath"
+	"strings"
 
 	"github.com/BurntSushi/toml"
 
-	"github.com/charlesbases/protoc-gen-swagger/logger"
+	"github.com/charlesbases/protoc-gen-swagger/diagnostic"
 )
 
 const configfile = "swagger.toml"
 
 var conf = new(config)
 
+// path 配置文件路径
+var path string
+
 // config .
 type config struct {
-	Host   string
-	Title  string
+	Host  string
+	Title string
+	// Version 文档版本. 为空时使用 proto 文件 openapiv2_swagger option 中的 info.version 或 proto 文件内容 hash. 参数 version=v1.0.0
+	Version string
+	// Header map[name]description. Deprecated: use Headers
 	Header map[string]string
+	// Headers header in request
+	Headers []*Header
+	Query   query
+	// Operation operation config
+	Operation operation
+	// Error error response config
+	Error errorModel
+	// Envelope response envelope config
+	Envelope envelope
+	// Security security config
+	Security security
+	// Example 生成请求与响应示例. 参数 example=true
+	Example bool
+	// Int64 64 位整数类型. string: 与 protojson 一致, 以字符串表示; number: 默认值. 参数 int64=string|number
+	Int64 string
+	// Enum 枚举表示方式. name: 默认值, 名称; number: 数值; both: 名称与数值均可. 参数 enum=name|number|both
+	Enum string
+	// StripEnumPrefix x-enum-varnames 中移除枚举值的枚举名前缀. e.g. STATUS_OK => OK. 参数 strip_enum_prefix=true
+	StripEnumPrefix bool `toml:"strip_enum_prefix"`
+	// KeepUnused 保留未被 api 引用的 message 与 enum. 参数 keep_unused=true
+	KeepUnused bool `toml:"keep_unused"`
+	// Filter service 与 rpc 过滤
+	Filter filter
+	// Order properties、tags、paths 与 query 参数顺序. declaration: 默认值, proto 声明顺序; alphabetical: 字母顺序. 参数 order=declaration|alphabetical
+	Order string
+	// Format 文档格式. json: 默认值; yaml. 参数 format=json|yaml
+	Format string
+	// Lint api lint
+	Lint lint
+	// Breaking 上一版本文档路径. 与生成的文档比较, 存在不兼容变更时生成失败. 参数 breaking=swagger/api.json
+	Breaking string
+	// Changelog 上一版本文档路径. 与生成的文档比较, 生成 Markdown 格式的变更日志 <package>.changelog.md. 参数 changelog=swagger/v1.json
+	Changelog string
+	// Visibility 文档受众. public: 隐藏 @internal 与 @hidden; internal: 仅隐藏 @hidden. 参数 visibility=public|internal
+	Visibility string
+}
+
+// operation swagger operation 配置
+type operation struct {
+	// ID operationId 模板. 支持 {package} {service} {method}. 默认 {service}_{method}
+	ID string
+}
+
+// Header 请求头
+type Header struct {
+	Name        string
+	Description string
+	Required    bool
+	// Type 默认 string
+	Type    string
+	Format  string
+	Enum    []string
+	Default string
+	Example string
+	// Include 生效的 service、rpc 或 path, 支持 "*". 为空时全部生效. e.g. ["Users", "Orders.Get*", "/api/v1/*"]
+	Include []string
+	// Exclude 不生效的 service、rpc 或 path, 支持 "*"
+	Exclude []string
+}
+
+// lint api lint 配置. 参数 lint=true
+type lint struct {
+	// Enabled 启用 lint
+	Enabled bool
+	// Fail 违反任意规则时生成失败, 即所有规则级别为 error
+	Fail bool
+	// Rules 规则级别. map[rule]level. level: off, warning (默认值), error
+	Rules map[string]string
+}
+
+// filter service 与 rpc 过滤. 参数 include=Users.*,Orders.Get* exclude=*.Internal*
+type filter struct {
+	// Include 保留的 service 或 rpc, 支持 "*". 为空时全部保留. e.g. ["Users", "Orders.Get*"]
+	Include []string
+	// Exclude 移除的 service 或 rpc, 支持 "*"
+	Exclude []string
+}
+
+// query GET 请求参数配置
+type query struct {
+	// Depth 嵌套 message 展开层数
+	Depth int
+}
+
+// errorModel 错误响应配置
+type errorModel struct {
+	// Name 错误响应结构名. 默认 Error
+	Name string
+	// Message 使用 proto message 作为错误响应结构. 支持 google.rpc.Status
+	Message string
+	// Codes 错误响应码. 默认 ["default"]. e.g. ["default"] ["400", "500"]
+	Codes []string
+	// Fields 自定义错误响应结构
+	Fields []*Field
+}
+
+// envelope 响应包装结构配置. e.g. {"code":0,"msg":"ok","data":<Response>}
+type envelope struct {
+	// Data rpc 响应所在字段. 为空时不包装响应
+	Data string
+	// Fields 包装结构其他字段
+	Fields []*Field
+}
+
+// security 认证配置
+type security struct {
+	// Requirements 全局认证, 多个为任选其一. e.g. ["ApiKeyAuth"] ["OAuth2 read write"]
+	Requirements []string
+	// Definitions 认证方式. map[name]*SecurityDefinition
+	Definitions map[string]*SecurityDefinition
+}
+
+// SecurityDefinition 认证方式
+type SecurityDefinition struct {
+	// Type apiKey, basic, bearer, oauth2
+	Type        string
+	Description string
+	// Name apiKey name
+	Name string
+	// In apiKey position. header, query
+	In string
+	// Flow oauth2 flow. implicit, password, application, accessCode
+	Flow             string
+	AuthorizationURL string
+	TokenURL         string
+	// Scopes oauth2 scopes. map[scope]description
+	Scopes map[string]string
+}
+
+// Field 自定义结构字段
+type Field struct {
+	Name        string
+	Type        string
+	Format      string
+	Description string
+	// Ref 引用 proto message. e.g. Status
+	Ref string
 }
 
 // Get .
@@ -24,6 +170,14 @@ func Get() *config {
 	return conf
 }
 
+// Position 配置文件位置, 用于诊断信息
+func Position() diagnostic.Position {
+	if len(path) != 0 {
+		return diagnostic.Position{File: path}
+	}
+	return diagnostic.Position{File: configfile}
+}
+
 // Parse .
 func Parse(f string) {
 	if len(f) == 0 {
@@ -31,9 +185,21 @@ func Parse(f string) {
 	}
 	abspath, err := filepath.Abs(f)
 	if err != nil {
-		logger.Fatal(err)
+		diagnostic.Errorf(Position(), "%v", err)
+		return
 	}
-	if _, err := toml.DecodeFile(filepath.Join(abspath, configfile), conf); err != nil {
-		logger.Fatal(err)
+
+	path = filepath.Join(abspath, configfile)
+	if _, err := toml.DecodeFile(path, conf); err != nil {
+		var perr toml.ParseError
+		if errors.As(err, &perr) {
+			var message = perr.Message
+			if len(message) == 0 {
+				message = strings.TrimPrefix(perr.Error(), fmt.Sprintf("toml: line %d: ", perr.Position.Line))
+			}
+			diagnostic.Errorf(diagnostic.Position{File: path, Line: perr.Position.Line}, "%s", message)
+			return
+		}
+		diagnostic.Errorf(Position(), "%v", err)
 	}
 }
@@ -0,0 +1,110 @@
+package diagnostic
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/charlesbases/protoc-gen-swagger/logger"
+)
+
+// Position source position. e.g. users.proto:12:3
+type Position struct {
+	File   string
+	Line   int
+	Column int
+}
+
+// String file:line:col
+func (p Position) String() string {
+	switch {
+	case p.Line == 0:
+		return p.File
+	case p.Column == 0:
+		return p.File + ":" + strconv.Itoa(p.Line)
+	default:
+		return p.File + ":" + strconv.Itoa(p.Line) + ":" + strconv.Itoa(p.Column)
+	}
+}
+
+// Severity .
+type Severity string
+
+const (
+	// SeverityWarning 输出至 stderr, 不影响生成
+	SeverityWarning Severity = "warning"
+	// SeverityError 通过 CodeGeneratorResponse.Error 返回, 不生成文件
+	SeverityError Severity = "error"
+)
+
+// Diagnostic 诊断信息
+type Diagnostic struct {
+	Severity Severity
+	Position Position
+	Message  string
+}
+
+// String file:line:col: severity: message
+func (d *Diagnostic) String() string {
+	if position := d.Position.String(); len(position) != 0 {
+		return position + ": " + string(d.Severity) + ": " + d.Message
+	}
+	return string(d.Severity) + ": " + d.Message
+}
+
+var (
+	locker      sync.Mutex
+	diagnostics = make([]*Diagnostic, 0)
+)
+
+// report .
+func report(severity Severity, position Position, format string, v ...interface{}) *Diagnostic {
+	var d = &Diagnostic{Severity: severity, Position: position, Message: fmt.Sprintf(format, v...)}
+
+	locker.Lock()
+	diagnostics = append(diagnostics, d)
+	locker.Unlock()
+	return d
+}
+
+// Warnf 记录警告并输出至 stderr
+func Warnf(position Position, format string, v ...interface{}) {
+	logger.Warn(report(SeverityWarning, position, format, v...))
+}
+
+// Errorf 记录错误. 错误在生成结束后通过 CodeGeneratorResponse.Error 返回
+func Errorf(position Position, format string, v ...interface{}) {
+	report(SeverityError, position, format, v...)
+}
+
+// List 诊断信息列表, 按记录顺序
+func List() []*Diagnostic {
+	locker.Lock()
+	defer locker.Unlock()
+
+	var list = make([]*Diagnostic, len(diagnostics))
+	copy(list, diagnostics)
+	return list
+}
+
+// Failed 是否存在错误
+func Failed() bool {
+	for _, d := range List() {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Error 错误信息, 每行一个. 无错误时为空
+func Error() string {
+	var errs = make([]string, 0)
+	for _, d := range List() {
+		if d.Severity == SeverityError {
+			errs = append(errs, d.String())
+		}
+	}
+	return strings.Join(errs, "\n")
+}
@@ -0,0 +1,174 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Changelog Markdown 格式的变更日志. 按 tag 分组, 包含 service 与 rpc 注释
+func (d *Diff) Changelog() string {
+	var b strings.Builder
+	b.WriteString("# Changelog\n\n")
+	if d.Old.Info != nil && d.New.Info != nil {
+		b.WriteString(fmt.Sprintf("%s `%s` → `%s`\n\n", d.New.Info.Title, d.Old.Info.Version, d.New.Info.Version))
+	}
+
+	if len(d.Changes) == 0 {
+		b.WriteString("No API changes.\n")
+		return b.String()
+	}
+
+	var tags, groups = d.group(d.Changes)
+	for idx, tag := range tags {
+		if idx != 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("## " + tag + "\n")
+		if description := d.tag(tag); len(description) != 0 && description != tag {
+			b.WriteString("\n" + description + "\n")
+		}
+		d.changelog(&b, groups[tag])
+	}
+	return b.String()
+}
+
+// changelog 单个 tag 的变更
+func (d *Diff) changelog(b *strings.Builder, changes []*Change) {
+	var added, removed, modified, schemas, enums = make([]*Change, 0), make([]*Change, 0), make([]*Change, 0), make([]*Change, 0), make([]*Change, 0)
+	for _, change := range changes {
+		switch {
+		case change.Kind == KindEnum:
+			enums = append(enums, change)
+		case len(change.Definition) != 0:
+			schemas = append(schemas, change)
+		case change.Kind == KindAdded:
+			added = append(added, change)
+		case change.Kind == KindRemoved:
+			removed = append(removed, change)
+		default:
+			modified = append(modified, change)
+		}
+	}
+
+	section(b, "Added endpoints", added, func(c *Change) string {
+		return entry(c.Operation, d.summary(c.Operation))
+	})
+	section(b, "Removed endpoints", removed, func(c *Change) string {
+		return entry(c.Operation, d.summary(c.Operation)) + breaking(c)
+	})
+	nested(b, "Modified endpoints", modified, func(c *Change) string { return c.Operation }, d.summary)
+	nested(b, "Schema changes", schemas, func(c *Change) string { return c.Definition }, d.description)
+	section(b, "New enum values", enums, func(c *Change) string {
+		return "`" + c.Subject + "`: " + c.Message
+	})
+}
+
+// section 变更列表, 每行一个
+func section(b *strings.Builder, title string, changes []*Change, line func(c *Change) string) {
+	if len(changes) == 0 {
+		return
+	}
+	b.WriteString("\n### " + title + "\n\n")
+	for _, change := range changes {
+		b.WriteString("- " + line(change) + "\n")
+	}
+}
+
+// nested 按 api 或 Definition 合并的变更列表
+func nested(b *strings.Builder, title string, changes []*Change, parent func(c *Change) string, description func(name string) string) {
+	if len(changes) == 0 {
+		return
+	}
+
+	var names = make([]string, 0)
+	var groups = make(map[string][]*Change, 0)
+	for _, change := range changes {
+		var name = parent(change)
+		if _, found := groups[name]; !found {
+			names = append(names, name)
+		}
+		groups[name] = append(groups[name], change)
+	}
+
+	b.WriteString("\n### " + title + "\n\n")
+	for _, name := range names {
+		b.WriteString("- " + entry(name, description(name)) + "\n")
+		for _, change := range groups[name] {
+			b.WriteString("  - " + detail(change, name) + breaking(change) + "\n")
+		}
+	}
+}
+
+// entry e.g. `GET /users/{id}` 获取用户
+func entry(name string, description string) string {
+	if len(description) != 0 {
+		return "`" + name + "` " + description
+	}
+	return "`" + name + "`"
+}
+
+// detail 相对于 api 或 Definition 的变更说明. e.g. `request body.name`: field added
+func detail(c *Change, parent string) string {
+	switch {
+	case c.Subject == parent:
+		return c.Message
+	case strings.HasPrefix(c.Subject, parent):
+		return "`" + strings.TrimLeft(strings.TrimPrefix(c.Subject, parent), " .") + "`: " + c.Message
+	default:
+		return "`" + c.Subject + "`: " + c.Message
+	}
+}
+
+// breaking 不兼容变更标记
+func breaking(c *Change) string {
+	if c.Breaking {
+		return " **(breaking)**"
+	}
+	return ""
+}
+
+// tag service 注释
+func (d *Diff) tag(name string) string {
+	for _, spec := range []*Spec{d.New, d.Old} {
+		for _, tag := range spec.Tags {
+			if tag.Name == name {
+				return firstline(tag.Description)
+			}
+		}
+	}
+	return ""
+}
+
+// summary rpc 注释
+func (d *Diff) summary(operation string) string {
+	for _, spec := range []*Spec{d.New, d.Old} {
+		for _, r := range routes(spec) {
+			if r.String() == operation {
+				var o, _ = spec.operation(r)
+				if len(o.Summary) != 0 {
+					return firstline(o.Summary)
+				}
+				return firstline(o.Description)
+			}
+		}
+	}
+	return ""
+}
+
+// description Definition 注释
+func (d *Diff) description(name string) string {
+	for _, spec := range []*Spec{d.New, d.Old} {
+		if s, found := spec.Definitions[name]; found {
+			return firstline(s.Description)
+		}
+	}
+	return ""
+}
+
+// firstline 多行注释的第一行
+func firstline(s string) string {
+	if i := strings.Index(s, "\n"); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
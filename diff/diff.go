@@ -0,0 +1,468 @@
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Kind 变更类型
+type Kind string
+
+const (
+	// KindAdded 新增的 api 或 Definition
+	KindAdded Kind = "added"
+	// KindRemoved 移除的 api 或 Definition
+	KindRemoved Kind = "removed"
+	// KindModified api 或 Definition 中的变更. e.g. 参数、字段、类型
+	KindModified Kind = "modified"
+	// KindEnum 新增的枚举值
+	KindEnum Kind = "enum"
+)
+
+// Change 文档变更
+type Change struct {
+	// Tags 受影响的 api tag. 未被 api 引用的 Definition 为空
+	Tags []string
+	Kind Kind
+	// Operation 变更所属的 api. e.g. GET /users/{id}
+	Operation string
+	// Definition 变更所属的 Definition
+	Definition string
+	// Subject 变更对象. e.g. GET /users/{id}, Request.name
+	Subject string
+	// Message 变更说明
+	Message string
+	// Breaking 是否为不兼容变更
+	Breaking bool
+}
+
+// String subject: message
+func (c *Change) String() string {
+	return c.Subject + ": " + c.Message
+}
+
+// Diff 两个版本文档的变更
+type Diff struct {
+	Old *Spec
+	New *Spec
+	// Changes 变更列表. 按 api、Definition 排序
+	Changes []*Change
+
+	// tags map[definition][]tag 引用 Definition 的 api tag
+	tags map[string][]string
+	// sides map[definition]side 引用 Definition 的请求或响应
+	sides map[string]side
+}
+
+// Compare 比较两个版本的文档
+func Compare(old, new *Spec) *Diff {
+	var d = &Diff{Old: old, New: new, Changes: make([]*Change, 0), tags: make(map[string][]string, 0), sides: make(map[string]side, 0)}
+	d.references(old)
+	d.references(new)
+
+	d.operations()
+	d.definitions()
+	return d
+}
+
+// Breaking 不兼容变更
+func (d *Diff) Breaking() []*Change {
+	var changes = make([]*Change, 0)
+	for _, change := range d.Changes {
+		if change.Breaking {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// side 结构的使用位置. 决定字段变更是否不兼容
+type side int
+
+const (
+	// sideRequest 请求参数. 新增必填字段、移除枚举值为不兼容变更
+	sideRequest side = 1 << iota
+	// sideResponse 响应. 必填字段改为可选为不兼容变更
+	sideResponse
+)
+
+// scope 变更所属的 api 或 Definition
+type scope struct {
+	tags       []string
+	operation  string
+	definition string
+	// side 未被 api 引用的 Definition 为 0, 其变更均不是不兼容变更
+	side side
+}
+
+// in 指定使用位置的 scope
+func (s scope) in(side side) scope {
+	s.side = side
+	return s
+}
+
+// request 是否用于请求
+func (s scope) request() bool {
+	return s.side&sideRequest != 0
+}
+
+// response 是否用于响应
+func (s scope) response() bool {
+	return s.side&sideResponse != 0
+}
+
+// referenced 是否被 api 引用
+func (s scope) referenced() bool {
+	return s.side != 0
+}
+
+// report .
+func (d *Diff) report(s scope, kind Kind, subject string, breaking bool, format string, v ...interface{}) {
+	d.Changes = append(d.Changes, &Change{
+		Tags:       s.tags,
+		Kind:       kind,
+		Operation:  s.operation,
+		Definition: s.definition,
+		Subject:    subject,
+		Message:    fmt.Sprintf(format, v...),
+		Breaking:   breaking,
+	})
+}
+
+// route METHOD uri
+type route struct {
+	uri    string
+	method string
+}
+
+// String e.g. GET /users/{id}
+func (r route) String() string {
+	return strings.ToUpper(r.method) + " " + r.uri
+}
+
+// routes 按 uri、method 排序的 api 列表
+func routes(spec *Spec) []route {
+	var list = make([]route, 0)
+	for uri, operations := range spec.Paths {
+		for method := range operations {
+			list = append(list, route{uri: uri, method: method})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].uri != list[j].uri {
+			return list[i].uri < list[j].uri
+		}
+		return list[i].method < list[j].method
+	})
+	return list
+}
+
+// operation .
+func (spec *Spec) operation(r route) (*Operation, bool) {
+	operation, found := spec.Paths[r.uri][r.method]
+	return operation, found
+}
+
+// operations 比较 api. 以 operationId 识别 path 或 method 变更
+func (d *Diff) operations() {
+	var ids = make(map[string]route, 0)
+	for _, r := range routes(d.New) {
+		if operation, _ := d.New.operation(r); len(operation.OperationID) != 0 {
+			ids[operation.OperationID] = r
+		}
+	}
+
+	// 变更 path 或 method 后的 api
+	var moves = make(map[route]bool, 0)
+
+	for _, r := range routes(d.Old) {
+		var old, _ = d.Old.operation(r)
+		if new, found := d.New.operation(r); found {
+			d.operation(scope{tags: old.Tags, operation: r.String()}, old, new)
+			continue
+		}
+
+		// path 或 method 变更
+		if moved, found := ids[old.OperationID]; found && len(old.OperationID) != 0 {
+			if _, exist := d.Old.operation(moved); !exist {
+				moves[moved] = true
+
+				var new, _ = d.New.operation(moved)
+				var s = scope{tags: old.Tags, operation: moved.String()}
+				switch {
+				case moved.uri != r.uri && moved.method != r.method:
+					d.report(s, KindModified, r.String(), true, "moved to %s", moved)
+				case moved.uri != r.uri:
+					d.report(s, KindModified, r.String(), true, "path changed to %s", moved.uri)
+				default:
+					d.report(s, KindModified, r.String(), true, "method changed to %s", strings.ToUpper(moved.method))
+				}
+				d.operation(s, old, new)
+				continue
+			}
+		}
+
+		d.report(scope{tags: old.Tags, operation: r.String()}, KindRemoved, r.String(), true, "operation removed")
+	}
+
+	for _, r := range routes(d.New) {
+		if _, found := d.Old.operation(r); !found && !moves[r] {
+			var new, _ = d.New.operation(r)
+			d.report(scope{tags: new.Tags, operation: r.String()}, KindAdded, r.String(), false, "operation added")
+		}
+	}
+}
+
+// operation 比较 api 参数与响应
+func (d *Diff) operation(s scope, old, new *Operation) {
+	var subject = s.operation
+
+	var params = make(map[string]*Parameter, len(new.Parameters))
+	for _, param := range new.Parameters {
+		params[param.In+":"+param.Name] = param
+	}
+
+	var exists = make(map[string]bool, len(old.Parameters))
+	for _, param := range old.Parameters {
+		var key = param.In + ":" + param.Name
+		exists[key] = true
+
+		var np, found = params[key]
+		switch {
+		case !found:
+			d.report(s, KindModified, subject, true, "%s parameter %s removed", param.In, param.Name)
+			continue
+		case !param.Required && np.Required:
+			d.report(s, KindModified, subject, true, "%s parameter %s is now required", param.In, param.Name)
+		case param.Required && !np.Required:
+			d.report(s, KindModified, subject, false, "%s parameter %s is now optional", param.In, param.Name)
+		}
+
+		if param.In == "body" {
+			d.schema(s.in(sideRequest), subject+" request body", param.Schema, np.Schema)
+		} else {
+			d.schema(s.in(sideRequest), fmt.Sprintf("%s %s parameter %s", subject, param.In, param.Name), param.schema(), np.schema())
+		}
+	}
+	for _, param := range new.Parameters {
+		if !exists[param.In+":"+param.Name] {
+			if param.Required {
+				d.report(s, KindModified, subject, true, "new required %s parameter %s", param.In, param.Name)
+			} else {
+				d.report(s, KindModified, subject, false, "%s parameter %s added", param.In, param.Name)
+			}
+		}
+	}
+
+	// responses
+	for _, code := range keys(old.Responses) {
+		var rsp = old.Responses[code]
+		var nrsp, found = new.Responses[code]
+		switch {
+		case !found:
+			d.report(s, KindModified, subject, true, "response %s removed", code)
+		case rsp.Schema != nil && nrsp.Schema == nil:
+			d.report(s, KindModified, subject, true, "response %s body removed", code)
+		case rsp.Schema != nil:
+			d.schema(s.in(sideResponse), subject+" response "+code, rsp.Schema, nrsp.Schema)
+		}
+	}
+	for _, code := range keys(new.Responses) {
+		if _, found := old.Responses[code]; !found {
+			d.report(s, KindModified, subject, false, "response %s added", code)
+		}
+	}
+}
+
+// schema 非 body 参数类型
+func (param *Parameter) schema() *Schema {
+	return &Schema{Type: param.Type, Format: param.Format, Enum: param.Enum, Items: param.Items}
+}
+
+// definitions 比较 Definition. 未被 api 引用的 Definition 变更不是不兼容变更
+func (d *Diff) definitions() {
+	for _, name := range keys(d.Old.Definitions) {
+		var s = scope{tags: d.tags[name], definition: name, side: d.sides[name]}
+		var new, found = d.New.Definitions[name]
+		if !found {
+			d.report(s, KindRemoved, name, s.referenced(), "definition removed")
+			continue
+		}
+		d.schema(s, name, d.Old.Definitions[name], new)
+	}
+
+	for _, name := range keys(d.New.Definitions) {
+		if _, found := d.Old.Definitions[name]; !found {
+			d.report(scope{tags: d.tags[name], definition: name}, KindAdded, name, false, "definition added")
+		}
+	}
+}
+
+// schema 比较结构. $ref 仅比较名称, 引用的 Definition 单独比较
+func (d *Diff) schema(s scope, subject string, old, new *Schema) {
+	if old == nil || new == nil {
+		return
+	}
+
+	// type
+	if len(old.Ref) != 0 || len(new.Ref) != 0 || old.Type != new.Type || old.Format != new.Format {
+		if typename(old) != typename(new) {
+			d.report(s, KindModified, subject, s.referenced(), "type changed from %s to %s", typename(old), typename(new))
+		}
+		return
+	}
+
+	// enum. 响应中移除枚举值不影响客户端
+	var olds = make(map[string]bool, len(old.Enum))
+	for _, value := range old.Enum {
+		olds[fmt.Sprint(value)] = true
+	}
+	var news = make(map[string]bool, len(new.Enum))
+	for _, value := range new.Enum {
+		news[fmt.Sprint(value)] = true
+	}
+	for _, value := range old.Enum {
+		if !news[fmt.Sprint(value)] {
+			d.report(s, KindModified, subject, s.request(), "enum value %v removed", value)
+		}
+	}
+	for _, value := range new.Enum {
+		if !olds[fmt.Sprint(value)] {
+			d.report(s, KindEnum, subject, false, "enum value %v added", value)
+		}
+	}
+
+	d.schema(s, subject+"[]", old.Items, new.Items)
+	d.schema(s, subject+"{}", old.AdditionalProperties, new.AdditionalProperties)
+
+	// properties
+	for _, name := range keys(old.Properties) {
+		var nested, found = new.Properties[name]
+		if !found {
+			d.report(s, KindModified, subject+"."+name, s.referenced(), "field removed or renamed")
+			continue
+		}
+		d.schema(s, subject+"."+name, old.Properties[name], nested)
+	}
+	for _, name := range keys(new.Properties) {
+		if _, found := old.Properties[name]; !found {
+			d.report(s, KindModified, subject+"."+name, false, "field added")
+		}
+	}
+
+	// required. 请求中新增必填字段、响应中必填字段改为可选为不兼容变更
+	var required = make(map[string]bool, len(old.Required))
+	for _, name := range old.Required {
+		required[name] = true
+	}
+	for _, name := range new.Required {
+		if !required[name] {
+			d.report(s, KindModified, subject+"."+name, s.request(), "field is now required")
+		}
+		delete(required, name)
+	}
+	for _, name := range old.Required {
+		if required[name] {
+			d.report(s, KindModified, subject+"."+name, s.response(), "field is now optional")
+		}
+	}
+}
+
+// typename e.g. Request, string(int64), []integer(int32)
+func typename(s *Schema) string {
+	switch {
+	case s == nil:
+		return "none"
+	case len(s.Ref) != 0:
+		return refname(s.Ref)
+	case s.Type == "array":
+		return "[]" + typename(s.Items)
+	case len(s.Format) != 0:
+		return s.Type + "(" + s.Format + ")"
+	case len(s.Type) != 0:
+		return s.Type
+	default:
+		return "object"
+	}
+}
+
+// references 记录引用 Definition 的 api tag 与使用位置
+func (d *Diff) references(spec *Spec) {
+	for _, r := range routes(spec) {
+		var operation, _ = spec.operation(r)
+
+		var requests = make(map[string]bool, 0)
+		for _, param := range operation.Parameters {
+			spec.references(param.Schema, requests)
+			spec.references(param.Items, requests)
+		}
+		var responses = make(map[string]bool, 0)
+		for _, rsp := range operation.Responses {
+			spec.references(rsp.Schema, responses)
+		}
+
+		for name := range requests {
+			d.sides[name] |= sideRequest
+		}
+		for name := range responses {
+			d.sides[name] |= sideResponse
+		}
+
+		for _, refs := range []map[string]bool{requests, responses} {
+			for name := range refs {
+				for _, tag := range operation.Tags {
+					if !contains(d.tags[name], tag) {
+						d.tags[name] = append(d.tags[name], tag)
+					}
+				}
+			}
+		}
+	}
+}
+
+// references 递归查找 Schema 引用的 Definition
+func (spec *Spec) references(s *Schema, refs map[string]bool) {
+	if s == nil {
+		return
+	}
+	if len(s.Ref) != 0 {
+		var name = refname(s.Ref)
+		if !refs[name] {
+			refs[name] = true
+			spec.references(spec.Definitions[name], refs)
+		}
+	}
+	spec.references(s.Items, refs)
+	spec.references(s.AdditionalProperties, refs)
+	for _, nested := range s.Properties {
+		spec.references(nested, refs)
+	}
+}
+
+// keys 排序后的 key 列表
+func keys(m interface{}) []string {
+	var list = make([]string, 0)
+	switch v := m.(type) {
+	case map[string]*Response:
+		for key := range v {
+			list = append(list, key)
+		}
+	case map[string]*Schema:
+		for key := range v {
+			list = append(list, key)
+		}
+	}
+	sort.Strings(list)
+	return list
+}
+
+// contains .
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,210 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+)
+
+// object 含 name 与 status 字段的结构
+func object(required []string, enum ...interface{}) *Schema {
+	return &Schema{
+		Type:     "object",
+		Required: required,
+		Properties: map[string]*Schema{
+			"name":   {Type: "string"},
+			"status": {Type: "string", Enum: enum},
+		},
+	}
+}
+
+// definitions Req 用于请求, Rsp 用于响应, Unused 未被 api 引用. replaces 中的值替换同名 Definition, nil 为移除
+func definitions(replaces map[string]*Schema) map[string]*Schema {
+	var m = map[string]*Schema{
+		"Req":    object(nil, "ACTIVE", "BLOCKED"),
+		"Rsp":    object(nil, "ACTIVE", "BLOCKED"),
+		"Unused": object(nil),
+	}
+	for name, schema := range replaces {
+		if schema == nil {
+			delete(m, name)
+			continue
+		}
+		m[name] = schema
+	}
+	return m
+}
+
+// spec 单个 api 的文档. 请求 Req, 响应 response. uri 为空时无 api
+func spec(uri string, method string, response string, replaces map[string]*Schema) *Spec {
+	var s = &Spec{Paths: make(map[string]map[string]*Operation, 0), Definitions: definitions(replaces)}
+	if len(uri) != 0 {
+		s.Paths[uri] = map[string]*Operation{
+			method: {
+				Tags:        []string{"Users"},
+				OperationID: "Users_Update",
+				Parameters: []*Parameter{
+					{In: "path", Name: "id", Type: "string", Required: true},
+					{In: "query", Name: "status", Type: "string", Enum: []interface{}{"ACTIVE", "BLOCKED"}},
+					{In: "body", Name: "body", Schema: &Schema{Ref: "#/definitions/Req"}},
+				},
+				Responses: map[string]*Response{
+					"200": {Schema: &Schema{Ref: "#/definitions/" + response}},
+				},
+			},
+		}
+	}
+	return s
+}
+
+// base 变更前的文档
+func base() *Spec {
+	return spec("/users/{id}", "put", "Rsp", nil)
+}
+
+func TestCompare(t *testing.T) {
+	var cases = []struct {
+		name    string
+		old     *Spec
+		new     *Spec
+		changes []string
+	}{
+		{
+			name:    "unchanged",
+			old:     base(),
+			new:     base(),
+			changes: []string{},
+		},
+		{
+			name:    "operation removed",
+			old:     base(),
+			new:     spec("", "", "", nil),
+			changes: []string{"PUT /users/{id}: operation removed (breaking)"},
+		},
+		{
+			name:    "operation added",
+			old:     spec("", "", "", nil),
+			new:     base(),
+			changes: []string{"PUT /users/{id}: operation added"},
+		},
+		{
+			name:    "path changed",
+			old:     base(),
+			new:     spec("/v2/users/{id}", "put", "Rsp", nil),
+			changes: []string{"PUT /users/{id}: path changed to /v2/users/{id} (breaking)"},
+		},
+		{
+			name:    "method changed",
+			old:     base(),
+			new:     spec("/users/{id}", "patch", "Rsp", nil),
+			changes: []string{"PUT /users/{id}: method changed to PATCH (breaking)"},
+		},
+		{
+			name:    "moved",
+			old:     base(),
+			new:     spec("/v2/users/{id}", "patch", "Rsp", nil),
+			changes: []string{"PUT /users/{id}: moved to PATCH /v2/users/{id} (breaking)"},
+		},
+		{
+			name:    "request field required",
+			old:     base(),
+			new:     spec("/users/{id}", "put", "Rsp", map[string]*Schema{"Req": object([]string{"name"}, "ACTIVE", "BLOCKED")}),
+			changes: []string{"Req.name: field is now required (breaking)"},
+		},
+		{
+			name:    "response field required",
+			old:     base(),
+			new:     spec("/users/{id}", "put", "Rsp", map[string]*Schema{"Rsp": object([]string{"name"}, "ACTIVE", "BLOCKED")}),
+			changes: []string{"Rsp.name: field is now required"},
+		},
+		{
+			name:    "request field optional",
+			old:     spec("/users/{id}", "put", "Rsp", map[string]*Schema{"Req": object([]string{"name"}, "ACTIVE", "BLOCKED")}),
+			new:     base(),
+			changes: []string{"Req.name: field is now optional"},
+		},
+		{
+			name:    "response field optional",
+			old:     spec("/users/{id}", "put", "Rsp", map[string]*Schema{"Rsp": object([]string{"name"}, "ACTIVE", "BLOCKED")}),
+			new:     base(),
+			changes: []string{"Rsp.name: field is now optional (breaking)"},
+		},
+		{
+			name:    "shared field required",
+			old:     spec("/users/{id}", "put", "Req", nil),
+			new:     spec("/users/{id}", "put", "Req", map[string]*Schema{"Req": object([]string{"name"}, "ACTIVE", "BLOCKED")}),
+			changes: []string{"Req.name: field is now required (breaking)"},
+		},
+		{
+			name:    "unused field required",
+			old:     base(),
+			new:     spec("/users/{id}", "put", "Rsp", map[string]*Schema{"Unused": object([]string{"name"})}),
+			changes: []string{"Unused.name: field is now required"},
+		},
+		{
+			name:    "request enum value removed",
+			old:     base(),
+			new:     spec("/users/{id}", "put", "Rsp", map[string]*Schema{"Req": object(nil, "ACTIVE")}),
+			changes: []string{"Req.status: enum value BLOCKED removed (breaking)"},
+		},
+		{
+			name:    "response enum value removed",
+			old:     base(),
+			new:     spec("/users/{id}", "put", "Rsp", map[string]*Schema{"Rsp": object(nil, "ACTIVE")}),
+			changes: []string{"Rsp.status: enum value BLOCKED removed"},
+		},
+		{
+			name:    "enum value added",
+			old:     spec("/users/{id}", "put", "Rsp", map[string]*Schema{"Req": object(nil, "ACTIVE")}),
+			new:     base(),
+			changes: []string{"Req.status: enum value BLOCKED added"},
+		},
+		{
+			name: "query enum value removed",
+			old:  base(),
+			new: func() *Spec {
+				var s = base()
+				s.Paths["/users/{id}"]["put"].Parameters[1].Enum = []interface{}{"ACTIVE"}
+				return s
+			}(),
+			changes: []string{"PUT /users/{id} query parameter status: enum value BLOCKED removed (breaking)"},
+		},
+		{
+			name:    "field removed",
+			old:     base(),
+			new:     spec("/users/{id}", "put", "Rsp", map[string]*Schema{"Rsp": {Type: "object", Properties: map[string]*Schema{"name": {Type: "string"}}}}),
+			changes: []string{"Rsp.status: field removed or renamed (breaking)"},
+		},
+		{
+			name:    "unused definition removed",
+			old:     base(),
+			new:     spec("/users/{id}", "put", "Rsp", map[string]*Schema{"Unused": nil}),
+			changes: []string{"Unused: definition removed"},
+		},
+		{
+			name: "referenced definition removed",
+			old:  base(),
+			new:  spec("/users/{id}", "put", "User", map[string]*Schema{"Rsp": nil, "User": object(nil, "ACTIVE", "BLOCKED")}),
+			changes: []string{
+				"PUT /users/{id} response 200: type changed from Rsp to User (breaking)",
+				"Rsp: definition removed (breaking)",
+				"User: definition added",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var changes = make([]string, 0)
+			for _, change := range Compare(c.old, c.new).Changes {
+				if change.Breaking {
+					changes = append(changes, change.String()+" (breaking)")
+				} else {
+					changes = append(changes, change.String())
+				}
+			}
+			if !reflect.DeepEqual(changes, c.changes) {
+				t.Errorf("expected %q, got %q", c.changes, changes)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package diff
+
+import "strings"
+
+// untagged 未被 api 引用的 Definition 变更所在分组
+const untagged = "Other"
+
+// Report 按 tag 分组的变更说明. tag 顺序与文档一致, 未被 api 引用的 Definition 位于 Other 分组
+func (d *Diff) Report(changes []*Change) string {
+	var tags, groups = d.group(changes)
+
+	var b strings.Builder
+	for idx, tag := range tags {
+		if idx != 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("## " + tag + "\n\n")
+		for _, change := range groups[tag] {
+			b.WriteString("- " + change.String() + "\n")
+		}
+	}
+	return b.String()
+}
+
+// group 按 tag 分组. 影响多个 tag 的变更位于每个分组中
+func (d *Diff) group(changes []*Change) ([]string, map[string][]*Change) {
+	var tags = make([]string, 0)
+	var groups = make(map[string][]*Change, 0)
+
+	var add = func(tag string, change *Change) {
+		if _, found := groups[tag]; !found {
+			tags = append(tags, tag)
+		}
+		groups[tag] = append(groups[tag], change)
+	}
+
+	// 文档中 tag 的顺序
+	for _, spec := range []*Spec{d.New, d.Old} {
+		for _, tag := range spec.Tags {
+			if _, found := groups[tag.Name]; !found {
+				tags = append(tags, tag.Name)
+				groups[tag.Name] = nil
+			}
+		}
+	}
+
+	for _, change := range changes {
+		if len(change.Tags) == 0 {
+			add(untagged, change)
+			continue
+		}
+		for _, tag := range change.Tags {
+			add(tag, change)
+		}
+	}
+
+	// 移除无变更的 tag
+	var list = make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if len(groups[tag]) != 0 {
+			list = append(list, tag)
+		}
+	}
+	return list, groups
+}
@@ -0,0 +1,136 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Spec swagger 2.0 文档中用于比较的部分
+type Spec struct {
+	Info *Info  `json:"info"`
+	Tags []*Tag `json:"tags"`
+	// Paths map[uri][method]*Operation
+	Paths map[string]map[string]*Operation `json:"paths"`
+	// Definitions map[name]*Schema
+	Definitions map[string]*Schema `json:"definitions"`
+}
+
+// Info .
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// Tag .
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Operation .
+type Operation struct {
+	Tags        []string             `json:"tags"`
+	Summary     string               `json:"summary"`
+	Description string               `json:"description"`
+	OperationID string               `json:"operationId"`
+	Parameters  []*Parameter         `json:"parameters"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Parameter .
+type Parameter struct {
+	In          string        `json:"in"`
+	Name        string        `json:"name"`
+	Type        string        `json:"type"`
+	Format      string        `json:"format"`
+	Required    bool          `json:"required"`
+	Enum        []interface{} `json:"enum"`
+	Description string        `json:"description"`
+	Items       *Schema       `json:"items"`
+	Schema      *Schema       `json:"schema"`
+}
+
+// Response .
+type Response struct {
+	Description string  `json:"description"`
+	Schema      *Schema `json:"schema"`
+}
+
+// Schema .
+type Schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 string             `json:"type"`
+	Format               string             `json:"format"`
+	Description          string             `json:"description"`
+	Enum                 []interface{}      `json:"enum"`
+	Items                *Schema            `json:"items"`
+	AdditionalProperties *Schema            `json:"additionalProperties"`
+	Properties           map[string]*Schema `json:"properties"`
+	Required             []string           `json:"required"`
+}
+
+// Load 读取 json 或 yaml 格式的文档
+func Load(filename string) (*Spec, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	spec, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s failed. %v", filename, err)
+	}
+	return spec, nil
+}
+
+// Parse 解析 json 或 yaml 格式的文档
+func Parse(data []byte) (*Spec, error) {
+	var spec = new(Spec)
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "{") {
+		return spec, json.Unmarshal(data, spec)
+	}
+
+	// yaml 转换为 json
+	var value interface{}
+	if err := yaml.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(normalize(value))
+	if err != nil {
+		return nil, err
+	}
+	return spec, json.Unmarshal(data, spec)
+}
+
+// normalize yaml 中非 string 类型的 key 转换为 string. e.g. 200:
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, val := range v {
+			v[key] = normalize(val)
+		}
+		return v
+	case map[interface{}]interface{}:
+		var m = make(map[string]interface{}, len(v))
+		for key, val := range v {
+			m[fmt.Sprint(key)] = normalize(val)
+		}
+		return m
+	case []interface{}:
+		for idx, val := range v {
+			v[idx] = normalize(val)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// refname #/definitions/Request => Request
+func refname(ref string) string {
+	return strings.TrimPrefix(ref, "#/definitions/")
+}
@@ -7,4 +7,5 @@ require (
 	github.com/charlesbases/colors v1.0.0
 	github.com/charlesbases/protobuf v1.0.0
 	google.golang.org/protobuf v1.28.0
+	gopkg.in/yaml.v3 v3.0.1
 )
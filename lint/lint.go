@@ -0,0 +1,229 @@
+package lint
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/charlesbases/protoc-gen-swagger/conf"
+	"github.com/charlesbases/protoc-gen-swagger/diagnostic"
+	"github.com/charlesbases/protoc-gen-swagger/protoc"
+	"github.com/charlesbases/protoc-gen-swagger/swagger"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// rules
+const (
+	// RuleRPCComment rpc 缺少注释
+	RuleRPCComment = "rpc_comment"
+	// RuleFieldComment message 字段缺少注释
+	RuleFieldComment = "field_comment"
+	// RuleQueryField GET 请求中无法作为 query 参数的字段. e.g. map, repeated message, 循环引用或超出 [query].depth 的 message
+	RuleQueryField = "query_field"
+	// RulePathParam path 参数不存在于请求 message 中
+	RulePathParam = "path_param"
+	// RuleDuplicatePath 重复路由. path 参数名不同视为相同路由. e.g. /users/{id} 与 /users/{user_id}
+	RuleDuplicatePath = "duplicate_path"
+	// RuleFieldSnakeCase 字段名不是 snake_case
+	RuleFieldSnakeCase = "field_snake_case"
+	// RuleEnumZeroValue 枚举零值不是 UNSPECIFIED. e.g. STATUS_UNSPECIFIED = 0
+	RuleEnumZeroValue = "enum_zero_value"
+)
+
+// rules 全部规则
+var rules = map[string]bool{
+	RuleRPCComment:     true,
+	RuleFieldComment:   true,
+	RuleQueryField:     true,
+	RulePathParam:      true,
+	RuleDuplicatePath:  true,
+	RuleFieldSnakeCase: true,
+	RuleEnumZeroValue:  true,
+}
+
+// level
+const (
+	// LevelOff 关闭规则
+	LevelOff = "off"
+	// LevelWarning 输出警告, 默认值
+	LevelWarning = "warning"
+	// LevelError 生成失败
+	LevelError = "error"
+)
+
+// snakeCase e.g. user_id
+var snakeCase = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+
+// pathParam path 参数. e.g. {id} {name=users/*}
+var pathParam = regexp.MustCompile(`\{[^}]*\}`)
+
+// Run 检查 Package, 违反规则时通过 diagnostic 输出
+func Run(p *protoc.Package) {
+	validate()
+
+	var l = &linter{p: p, routes: make(map[string]*protoc.ServiceMethod, 0)}
+	for _, srv := range p.Services {
+		for _, m := range srv.Methods {
+			l.method(srv, m)
+		}
+	}
+	// 仅检查需要生成的 proto 文件, 忽略依赖的 proto 文件
+	for _, mess := range p.Messages {
+		if p.Files[mess.Position.File] {
+			l.message(mess)
+		}
+	}
+	for _, enum := range p.Enums {
+		if p.Files[enum.Position.File] {
+			l.enum(enum)
+		}
+	}
+}
+
+// validate 检查 swagger.toml 中的规则配置
+func validate() {
+	var names = make([]string, 0, len(conf.Get().Lint.Rules))
+	for name := range conf.Get().Lint.Rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !rules[name] {
+			diagnostic.Warnf(conf.Position(), "unknown lint rule %q", name)
+		}
+		switch level := conf.Get().Lint.Rules[name]; level {
+		case LevelOff, LevelWarning, LevelError:
+		default:
+			diagnostic.Warnf(conf.Position(), "invalid lint level %q for %s. off, warning or error", level, name)
+		}
+	}
+}
+
+// level 规则级别
+func level(rule string) string {
+	var cfg = conf.Get().Lint
+	switch {
+	case cfg.Rules[rule] == LevelOff:
+		return LevelOff
+	case cfg.Fail, cfg.Rules[rule] == LevelError:
+		return LevelError
+	default:
+		return LevelWarning
+	}
+}
+
+// report .
+func report(rule string, position protoc.Position, format string, v ...interface{}) {
+	v = append(v, rule)
+	switch level(rule) {
+	case LevelOff:
+	case LevelError:
+		diagnostic.Errorf(position, format+" (%s)", v...)
+	default:
+		diagnostic.Warnf(position, format+" (%s)", v...)
+	}
+}
+
+// linter .
+type linter struct {
+	p *protoc.Package
+
+	// routes map[method uri]*protoc.ServiceMethod
+	routes map[string]*protoc.ServiceMethod
+}
+
+// method .
+func (l *linter) method(srv *protoc.Service, m *protoc.ServiceMethod) {
+	// comment. 无注释时 Description 为 rpc 名称
+	if m.Description == m.Name {
+		report(RuleRPCComment, m.Position, "rpc %s.%s has no comment", srv.Name, m.Name)
+	}
+
+	uri, params := protoc.ParsePathTemplate(m.Path)
+
+	// duplicate path
+	var route = m.Method.String() + " " + pathParam.ReplaceAllString(uri, "{}")
+	if exist, found := l.routes[route]; found {
+		report(RuleDuplicatePath, m.Position, "route %s [%s] of %s.%s conflicts with %s at %s", uri, m.Method, srv.Name, m.Name, exist.Name, exist.Position)
+	} else {
+		l.routes[route] = m
+	}
+
+	// path param
+	var inpath = make(map[string]bool, len(params))
+	for _, param := range params {
+		inpath[param.Name] = true
+		if l.p.Field(m.RequestName, param.Fields()) == nil {
+			report(RulePathParam, m.Position, "path parameter {%s} of %s.%s not found in %s", param.Name, srv.Name, m.Name, m.RequestName)
+		}
+	}
+
+	// query
+	if m.Method == protoc.MethodGet && m.Consume != "multipart/form-data" {
+		l.query(srv, m, m.RequestName, "", inpath, map[string]bool{m.RequestName: true}, swagger.QueryDepth())
+	}
+}
+
+// query GET 请求中无法作为 query 参数的字段. 与 swagger 中 query 参数的展开规则一致
+func (l *linter) query(srv *protoc.Service, m *protoc.ServiceMethod, message string, prefix string, inpath map[string]bool, visited map[string]bool, depth int) {
+	mess, found := l.p.MessageDic[message]
+	if !found {
+		return
+	}
+
+	for _, mf := range mess.Fields {
+		var name = prefix + mf.ProtoName
+		if inpath[name] || mf.OutputOnly {
+			continue
+		}
+		if mf.ProtoType != descriptorpb.FieldDescriptorProto_TYPE_MESSAGE && mf.ProtoType != descriptorpb.FieldDescriptorProto_TYPE_GROUP {
+			continue
+		}
+
+		switch {
+		case protoc.IsEntry(mf):
+			report(RuleQueryField, mf.Position, "map field %s is dropped from query of GET %s.%s", name, srv.Name, m.Name)
+		case mf.ProtoLaber == descriptorpb.FieldDescriptorProto_LABEL_REPEATED:
+			report(RuleQueryField, mf.Position, "repeated message field %s is dropped from query of GET %s.%s", name, srv.Name, m.Name)
+		case visited[mf.ProtoTypeName]:
+			report(RuleQueryField, mf.Position, "recursive message field %s is dropped from query of GET %s.%s", name, srv.Name, m.Name)
+		case depth <= 1:
+			report(RuleQueryField, mf.Position, "message field %s exceeds query depth %d, dropped from query of GET %s.%s", name, swagger.QueryDepth(), srv.Name, m.Name)
+		default:
+			visited[mf.ProtoTypeName] = true
+			l.query(srv, m, mf.ProtoTypeName, name+".", inpath, visited, depth-1)
+			delete(visited, mf.ProtoTypeName)
+		}
+	}
+}
+
+// message .
+func (l *linter) message(mess *protoc.Message) {
+	if mess.Entry {
+		return
+	}
+
+	for _, mf := range mess.Fields {
+		// comment. 无注释时 Description 为字段名
+		if mf.Description == mf.ProtoName {
+			report(RuleFieldComment, mf.Position, "field %s.%s has no comment", mess.Name, mf.ProtoName)
+		}
+		if !snakeCase.MatchString(mf.ProtoName) {
+			report(RuleFieldSnakeCase, mf.Position, "field %s.%s should be snake_case", mess.Name, mf.ProtoName)
+		}
+	}
+}
+
+// enum .
+func (l *linter) enum(enum *protoc.Enum) {
+	for _, field := range enum.Fields {
+		if field.Value == 0 {
+			if !strings.HasSuffix(field.Name, "UNSPECIFIED") {
+				report(RuleEnumZeroValue, field.Position, "zero value %s of enum %s should be *_UNSPECIFIED", field.Name, enum.Name)
+			}
+			return
+		}
+	}
+	report(RuleEnumZeroValue, enum.Position, "enum %s has no zero value *_UNSPECIFIED", enum.Name)
+}
@@ -1,21 +1,48 @@
 package logger
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/charlesbases/colors"
 )
 
+// colorful stderr 为终端时输出颜色. 重定向至文件或 CI 日志时不输出 ANSI 颜色
+var colorful = isTerminal(os.Stderr)
+
+// isTerminal .
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
 // Fatal .
 func Fatal(v ...interface{}) {
-	os.Stderr.WriteString(colors.RedSprint(v...))
+	if colorful {
+		os.Stderr.WriteString(colors.RedSprint(v...))
+	} else {
+		os.Stderr.WriteString(fmt.Sprint(v...))
+	}
 	os.Stderr.WriteString("\n")
 	os.Exit(1)
 }
 
 // Fatalf .
 func Fatalf(format string, v ...interface{}) {
-	os.Stderr.WriteString(colors.RedSprintf(format, v...))
+	Fatal(fmt.Sprintf(format, v...))
+}
+
+// Warn .
+func Warn(v ...interface{}) {
+	if colorful {
+		os.Stderr.WriteString(colors.YellowSprint(v...))
+	} else {
+		os.Stderr.WriteString(fmt.Sprint(v...))
+	}
 	os.Stderr.WriteString("\n")
-	os.Exit(1)
+}
+
+// Warnf .
+func Warnf(format string, v ...interface{}) {
+	Warn(fmt.Sprintf(format, v...))
 }
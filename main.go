@@ -1,17 +1,45 @@
 package main
 
 import (
+	"os"
+
+	"github.com/charlesbases/protoc-gen-swagger/conf"
+	"github.com/charlesbases/protoc-gen-swagger/lint"
 	"github.com/charlesbases/protoc-gen-swagger/protoc"
 	"github.com/charlesbases/protoc-gen-swagger/swagger"
 	"google.golang.org/protobuf/types/pluginpb"
 )
 
 func main() {
+	// 子命令
+	if len(os.Args) > 1 {
+		command(os.Args[1:])
+		return
+	}
+
 	protoc.Plugin(func(p *protoc.Package) *pluginpb.CodeGeneratorResponse {
 		var rsp = new(pluginpb.CodeGeneratorResponse)
 
+		// api lint
+		if conf.Get().Lint.Enabled {
+			lint.Run(p)
+		}
+
 		// swagger api
-		rsp.File = append(rsp.File, swagger.New(p).Generater())
+		var file = swagger.New(p).Generater()
+		rsp.File = append(rsp.File, file)
+
+		// 不兼容变更检查
+		if previous := conf.Get().Breaking; len(previous) != 0 && file != nil {
+			breaking(previous, file)
+		}
+
+		// 变更日志
+		if previous := conf.Get().Changelog; len(previous) != 0 && file != nil {
+			if log := changelog(previous, file); log != nil {
+				rsp.File = append(rsp.File, log)
+			}
+		}
 
 		return rsp
 	})
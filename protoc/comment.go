@@ -2,6 +2,7 @@ package protoc
 
 import (
 	"fmt"
+	"strings"
 )
 
 // comment path
@@ -47,12 +48,23 @@ type (
 	comments map[string]*comment
 
 	comment struct {
-		leading  string
-		trailing string
-		detached []string
+		leading    string
+		trailing   string
+		detached   []string
+		directives Directives
+		// position declaration position
+		position Position
 	}
 )
 
+// position get declaration position by path. 无 SourceCodeInfo 时为文件名
+func (cs comments) position(paths ...int) Position {
+	if comment, found := cs[fmt.Sprintf("%v", paths)]; found {
+		return comment.position
+	}
+	return cs[fmt.Sprintf("%v", []int{})].position
+}
+
 // comment get comment by path
 func (cs comments) comment(name string, paths ...int) string {
 	if comment, found := cs[fmt.Sprintf("%v", paths)]; found && comment.leading != "" {
@@ -61,16 +73,90 @@ func (cs comments) comment(name string, paths ...int) string {
 	return name
 }
 
+// directives get directives in comment by path
+func (cs comments) directives(paths ...int) Directives {
+	if comment, found := cs[fmt.Sprintf("%v", paths)]; found {
+		return comment.directives
+	}
+	return nil
+}
+
+// directiveNames 支持的注释指令. 其他以 "@" 开头的注释行保留在注释中. e.g. @deprecated, @see
+var directiveNames = map[string]bool{
+	"payload":  true,
+	"example":  true,
+	"response": true,
+	"security": true,
+	"envelope": true,
+	"hidden":   true,
+	"internal": true,
+	"required": true,
+	"readonly": true,
+}
+
+// parseDirectives 解析注释中的指令, 返回去除指令后的注释. 指令后可跟随 ``` 代码块. e.g.
+//
+//	// @payload file
+//	// @example request
+//	// ```json
+//	// {"id": 1}
+//	// ```
+func parseDirectives(source string, position Position) (string, Directives) {
+	var lines = make([]string, 0)
+	var directives = make(Directives, 0)
+
+	var sources = strings.Split(source, "\n")
+	for idx := 0; idx < len(sources); idx++ {
+		var line = sources[idx]
+
+		if text := uncomment(line); strings.HasPrefix(text, "@") {
+			var directive = &Directive{Name: strings.TrimSpace(text[1:]), Position: position}
+			if position.Line != 0 {
+				directive.Position.Line += idx
+			}
+			if i := strings.IndexAny(directive.Name, " \t"); i >= 0 {
+				directive.Value = strings.TrimSpace(directive.Name[i+1:])
+				directive.Name = directive.Name[:i]
+			}
+
+			if directiveNames[directive.Name] {
+				// code block
+				if idx+1 < len(sources) && strings.HasPrefix(uncomment(sources[idx+1]), "```") {
+					var block = make([]string, 0)
+					for idx += 2; idx < len(sources); idx++ {
+						if uncomment(sources[idx]) == "```" {
+							break
+						}
+						block = append(block, sources[idx])
+					}
+					directive.Block = strings.Join(block, "\n")
+				}
+
+				directives = append(directives, directive)
+				continue
+			}
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n"), directives
+}
+
+// uncomment 去除注释行首的 "*" 与空白
+func uncomment(line string) string {
+	return strings.TrimSpace(strings.TrimLeft(line, "* \t"))
+}
+
 // newPackage .
 func newPackage(name string) *Package {
 	return &Package{
 		Name:       name,
-		Version:    version(),
 		Services:   make([]*Service, 0),
 		Enums:      make([]*Enum, 0),
 		EnumDic:    make(map[string]*Enum, 0),
 		Messages:   make([]*Message, 0),
 		MessageDic: make(map[string]*Message, 0),
+		Files:      make(map[string]bool, 0),
+		Removed:    make(map[string]bool, 0),
 	}
 }
 
@@ -0,0 +1,186 @@
+package protoc
+
+import (
+	"github.com/charlesbases/protobuf/types/httppb"
+	"github.com/charlesbases/protobuf/types/servicepb"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// google/api/field_behavior.proto
+const (
+	// fieldBehaviorExtension google.api.field_behavior extension number
+	fieldBehaviorExtension protowire.Number = 1052
+
+	FieldBehaviorRequired   = 2
+	FieldBehaviorOutputOnly = 3
+	FieldBehaviorInputOnly  = 4
+)
+
+// google/api/visibility.proto
+const (
+	// visibilityExtension google.api.api_visibility, method_visibility, field_visibility, value_visibility extension number
+	visibilityExtension protowire.Number = 72295727
+	// visibilityRestriction google.api.VisibilityRule.restriction
+	visibilityRestriction protowire.Number = 2
+)
+
+// protoc-gen-openapiv2/options/annotations.proto
+const (
+	// openapiv2SwaggerExtension grpc.gateway.protoc_gen_openapiv2.options.openapiv2_swagger extension number
+	openapiv2SwaggerExtension protowire.Number = 1042
+	// openapiv2SwaggerInfo Swagger.info
+	openapiv2SwaggerInfo protowire.Number = 2
+	// openapiv2InfoVersion Info.version
+	openapiv2InfoVersion protowire.Number = 6
+)
+
+// parseMethodOptions .
+func parseMethodOptions(opts *descriptorpb.MethodOptions) *httppb.Http {
+	if opts != nil {
+		if exp, ok := proto.GetExtension(opts, httppb.E_Http).(*httppb.Http); ok {
+			return exp
+		}
+	}
+	return nil
+}
+
+// parseServiceOption .
+func parseServiceOption(opts *descriptorpb.ServiceOptions) *servicepb.Service {
+	if opts != nil {
+		if exp, ok := proto.GetExtension(opts, httppb.E_Http).(*servicepb.Service); ok {
+			return exp
+		}
+	}
+	return nil
+}
+
+// parseFieldBehavior 解析 google.api.field_behavior
+func parseFieldBehavior(opts *descriptorpb.FieldOptions) []uint64 {
+	var behaviors = make([]uint64, 0)
+	if opts == nil {
+		return behaviors
+	}
+
+	for _, value := range unknownExtension(opts, fieldBehaviorExtension) {
+		switch value.typ {
+		case protowire.VarintType:
+			if v, n := protowire.ConsumeVarint(value.raw); n >= 0 {
+				behaviors = append(behaviors, v)
+			}
+		case protowire.BytesType:
+			// packed
+			for packed := value.raw; len(packed) != 0; {
+				v, n := protowire.ConsumeVarint(packed)
+				if n < 0 {
+					break
+				}
+				behaviors = append(behaviors, v)
+				packed = packed[n:]
+			}
+		}
+	}
+	return behaviors
+}
+
+// parseVisibility 解析 google.api 的 visibility restriction. e.g. INTERNAL
+func parseVisibility(opts proto.Message) string {
+	if opts == nil {
+		return ""
+	}
+
+	var restriction string
+	for _, value := range unknownExtension(opts, visibilityExtension) {
+		if value.typ != protowire.BytesType {
+			continue
+		}
+
+		for _, v := range messageField(value.raw, visibilityRestriction) {
+			restriction = string(v)
+		}
+	}
+	return restriction
+}
+
+// parseFileVersion 解析 openapiv2_swagger option 中的 info.version
+func parseFileVersion(opts *descriptorpb.FileOptions) string {
+	if opts == nil {
+		return ""
+	}
+
+	var version string
+	for _, value := range unknownExtension(opts, openapiv2SwaggerExtension) {
+		if value.typ != protowire.BytesType {
+			continue
+		}
+
+		for _, info := range messageField(value.raw, openapiv2SwaggerInfo) {
+			for _, v := range messageField(info, openapiv2InfoVersion) {
+				version = string(v)
+			}
+		}
+	}
+	return version
+}
+
+// messageField 获取 message 中 bytes 类型字段的值. e.g. string, message
+func messageField(raw []byte, number protowire.Number) [][]byte {
+	var values = make([][]byte, 0)
+	for len(raw) != 0 {
+		num, typ, n := protowire.ConsumeTag(raw)
+		if n < 0 {
+			break
+		}
+		raw = raw[n:]
+
+		if num == number && typ == protowire.BytesType {
+			if v, n := protowire.ConsumeBytes(raw); n >= 0 {
+				values = append(values, v)
+			}
+		}
+		if n = protowire.ConsumeFieldValue(num, typ, raw); n < 0 {
+			break
+		}
+		raw = raw[n:]
+	}
+	return values
+}
+
+// rawValue unknown field value
+type rawValue struct {
+	typ protowire.Type
+	// raw varint 为编码后的值, bytes 为内容
+	raw []byte
+}
+
+// unknownExtension 获取未注册的 extension. 未注册的 extension 位于 unknown fields 中
+func unknownExtension(opts proto.Message, number protowire.Number) []*rawValue {
+	var values = make([]*rawValue, 0)
+
+	var raw = opts.ProtoReflect().GetUnknown()
+	for len(raw) != 0 {
+		num, typ, n := protowire.ConsumeTag(raw)
+		if n < 0 {
+			break
+		}
+		raw = raw[n:]
+
+		if n = protowire.ConsumeFieldValue(num, typ, raw); n < 0 {
+			break
+		}
+
+		if num == number {
+			switch typ {
+			case protowire.BytesType:
+				if v, m := protowire.ConsumeBytes(raw[:n]); m >= 0 {
+					values = append(values, &rawValue{typ: typ, raw: v})
+				}
+			default:
+				values = append(values, &rawValue{typ: typ, raw: raw[:n]})
+			}
+		}
+		raw = raw[n:]
+	}
+	return values
+}
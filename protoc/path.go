@@ -0,0 +1,92 @@
+package protoc
+
+import (
+	"regexp"
+	"strings"
+
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// PathParam path 参数. e.g. {user.id} {name=users/*}
+type PathParam struct {
+	// Name field path. e.g. user.id
+	Name string
+	// Pattern segment pattern. e.g. users/*
+	Pattern string
+}
+
+// Fields field path split by "."
+func (pp *PathParam) Fields() []string {
+	return strings.Split(pp.Name, ".")
+}
+
+// Regexp segment pattern to regexp. e.g. users/* => ^users/[^/]+$
+func (pp *PathParam) Regexp() string {
+	var segments = strings.Split(pp.Pattern, "/")
+	for idx, segment := range segments {
+		switch segment {
+		case "*":
+			segments[idx] = "[^/]+"
+		case "**":
+			segments[idx] = ".+"
+		default:
+			segments[idx] = regexp.QuoteMeta(segment)
+		}
+	}
+	return "^" + strings.Join(segments, "/") + "$"
+}
+
+// ParsePathTemplate 解析 path 模板, 返回 swagger path 与 path 参数列表.
+// e.g. /v1/{name=users/*}/books/{book.id} => /v1/{name}/books/{book.id}
+func ParsePathTemplate(template string) (string, []*PathParam) {
+	var uri strings.Builder
+	uri.Grow(len(template))
+
+	var params = make([]*PathParam, 0)
+	for len(template) != 0 {
+		l := strings.Index(template, "{")
+		if l < 0 {
+			break
+		}
+		r := strings.Index(template[l:], "}")
+		if r < 0 {
+			break
+		}
+		r += l
+
+		var param = &PathParam{Name: strings.TrimSpace(template[l+1 : r])}
+		if i := strings.Index(param.Name, "="); i >= 0 {
+			param.Pattern = strings.TrimSpace(param.Name[i+1:])
+			param.Name = strings.TrimSpace(param.Name[:i])
+		}
+		params = append(params, param)
+
+		uri.WriteString(template[:l])
+		uri.WriteString("{")
+		uri.WriteString(param.Name)
+		uri.WriteString("}")
+
+		template = template[r+1:]
+	}
+	uri.WriteString(template)
+
+	return uri.String(), params
+}
+
+// Field 根据字段路径查找 message 字段. e.g. [user id]
+func (p *Package) Field(message string, fields []string) *MessageField {
+	if mess, found := p.MessageDic[message]; found && len(fields) != 0 {
+		for _, mf := range mess.Fields {
+			if mf.ProtoName == fields[0] {
+				if len(fields) == 1 {
+					return mf
+				}
+				if (mf.ProtoType == descriptorpb.FieldDescriptorProto_TYPE_MESSAGE || mf.ProtoType == descriptorpb.FieldDescriptorProto_TYPE_GROUP) && !IsEntry(mf) {
+					return p.Field(mf.ProtoTypeName, fields[1:])
+				}
+				return nil
+			}
+		}
+	}
+	return nil
+}
@@ -9,51 +9,149 @@ import (
 	"sync"
 
 	"github.com/charlesbases/protobuf/types/httppb"
-	"github.com/charlesbases/protobuf/types/servicepb"
 	"github.com/charlesbases/protoc-gen-swagger/conf"
+	"github.com/charlesbases/protoc-gen-swagger/diagnostic"
 	"github.com/charlesbases/protoc-gen-swagger/logger"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/descriptorpb"
 	"google.golang.org/protobuf/types/pluginpb"
 )
 
-// Plugin .
+// Plugin 读取 os.Stdin 中的 CodeGeneratorRequest. 仅在无法读取 os.Stdin 时退出, 其他错误通过 CodeGeneratorResponse.Error 返回
 func Plugin(fn func(p *Package) *pluginpb.CodeGeneratorResponse) {
 	var buff = new(bytes.Buffer)
 	if _, err := io.Copy(buff, os.Stdin); err != nil {
 		logger.Fatal("read os.Stdin failed. ", err)
 	}
 
+	var rsp *pluginpb.CodeGeneratorResponse
 	var req = new(pluginpb.CodeGeneratorRequest)
 	if err := proto.Unmarshal(buff.Bytes(), req); err != nil {
-		logger.Fatal("unmarshal os.Stdin failed. ", err)
+		diagnostic.Errorf(Position{}, "unmarshal CodeGeneratorRequest failed. %v", err)
+		rsp = failure()
+	} else {
+		rsp = Generate(req, fn)
 	}
-	if len(req.GetFileToGenerate()) == 0 {
-		logger.Fatal("no file to generate")
+
+	data, err := proto.Marshal(rsp)
+	if err != nil {
+		diagnostic.Errorf(Position{}, "marshal CodeGeneratorResponse failed. %v", err)
+		data, _ = proto.Marshal(failure())
 	}
+	os.Stdout.Write(data)
+}
 
-	parseArgs(req)
+// Generate 生成文件. 存在错误时仅通过 CodeGeneratorResponse.Error 返回错误, 由 protoc 输出
+func Generate(req *pluginpb.CodeGeneratorRequest, fn func(p *Package) *pluginpb.CodeGeneratorResponse) *pluginpb.CodeGeneratorResponse {
+	var rsp = new(pluginpb.CodeGeneratorResponse)
 
-	if rsp, err := proto.Marshal(fn(parse(req))); err != nil {
-		logger.Fatal(err)
-	} else {
-		os.Stdout.Write(rsp)
+	if len(req.GetFileToGenerate()) == 0 {
+		diagnostic.Errorf(Position{}, "no file to generate")
+	}
+	if !diagnostic.Failed() {
+		parseArgs(req)
+	}
+	if !diagnostic.Failed() {
+		rsp = fn(parse(req))
 	}
+
+	if diagnostic.Failed() {
+		return failure()
+	}
+	return rsp
+}
+
+// failure 仅包含错误信息的响应
+func failure() *pluginpb.CodeGeneratorResponse {
+	var message = diagnostic.Error()
+	return &pluginpb.CodeGeneratorResponse{Error: &message}
 }
 
-// parseArgs 加载 protoc 传入的参数
+// parameters 插件参数
+var parameters = map[string]bool{
+	"confdir":           true,
+	"example":           true,
+	"visibility":        true,
+	"include":           true,
+	"exclude":           true,
+	"keep_unused":       true,
+	"int64":             true,
+	"enum":              true,
+	"strip_enum_prefix": true,
+	"order":             true,
+	"format":            true,
+	"version":           true,
+	"lint":              true,
+	"breaking":          true,
+	"changelog":         true,
+}
+
+// parseArgs 加载 protoc 传入的参数. 非参数名且不含 "=" 的值追加至上一个参数. e.g. include=Users.*,Orders.Get*
 func parseArgs(req *pluginpb.CodeGeneratorRequest) {
+	var args = make(map[string]string, 0)
+
+	var last string
 	for _, param := range strings.Split(req.GetParameter(), ",") {
 		var value string
 		if i := strings.Index(param, "="); i >= 0 {
 			value = param[i+1:]
 			param = param[0:i]
+		} else if len(last) != 0 && !parameters[param] {
+			args[last] += "," + param
+			continue
 		}
 
+		args[param] = value
+		last = param
+	}
+
+	// 解析基础配置文件. 参数优先于配置文件
+	if value, found := args["confdir"]; found {
+		conf.Parse(value)
+	}
+
+	for param, value := range args {
 		switch param {
-		// 解析基础配置文件
-		case "confdir":
-			conf.Parse(value)
+		// 生成请求与响应示例
+		case "example":
+			conf.Get().Example = boolean(value)
+		// 文档受众
+		case "visibility":
+			conf.Get().Visibility = value
+		// service 与 rpc 过滤
+		case "include":
+			conf.Get().Filter.Include = strings.Split(value, ",")
+		case "exclude":
+			conf.Get().Filter.Exclude = strings.Split(value, ",")
+		// 保留未被引用的 message 与 enum
+		case "keep_unused":
+			conf.Get().KeepUnused = boolean(value)
+		// 64 位整数类型
+		case "int64":
+			conf.Get().Int64 = value
+		// 枚举表示方式
+		case "enum":
+			conf.Get().Enum = value
+		case "strip_enum_prefix":
+			conf.Get().StripEnumPrefix = boolean(value)
+		// properties、tags、paths 与 query 参数顺序
+		case "order":
+			conf.Get().Order = value
+		// 文档格式
+		case "format":
+			conf.Get().Format = value
+		// 文档版本
+		case "version":
+			conf.Get().Version = value
+		// api lint
+		case "lint":
+			conf.Get().Lint.Enabled = boolean(value)
+		// 不兼容变更检查
+		case "breaking":
+			conf.Get().Breaking = value
+		// 变更日志
+		case "changelog":
+			conf.Get().Changelog = value
 		}
 	}
 }
@@ -61,57 +159,106 @@ func parseArgs(req *pluginpb.CodeGeneratorRequest) {
 // parse .
 func parse(req *pluginpb.CodeGeneratorRequest) *Package {
 	var p = newPackage(req.GetProtoFile()[0].GetPackage())
+	p.Version = version(req)
+	for _, filename := range req.GetFileToGenerate() {
+		p.Files[filename] = true
+	}
+
+	// 各文件并发解析, 按文件顺序合并以保持声明顺序
+	var files = make([]*file, len(req.GetProtoFile()))
 
 	var swg = sync.WaitGroup{}
 	swg.Add(len(req.GetProtoFile()))
 
 	for fidx := range req.GetProtoFile() {
-		go func(file *descriptorpb.FileDescriptorProto) {
-			if !strings.HasPrefix(file.GetPackage(), "google.protobuf") {
-				// parse comment
-				var cs = parseComments(file.SourceCodeInfo)
-
-				// parse enum
-				for idx, protoEnum := range file.GetEnumType() {
-					p.appendEnum(cs.parseEnum(protoEnum, COMMENT_PATH_ENUM, idx))
-				}
-
-				// parse message
-				for midx, protoMessage := range file.GetMessageType() {
-					var paths = []int{COMMENT_PATH_MESSAGE, midx}
-
-					for eidx, protoEnum := range protoMessage.GetEnumType() {
-						p.appendEnum(cs.parseMessageEnum(protoEnum, protoMessage.GetName(), append(paths, COMMENT_PATH_MESSAGE_ENUM, eidx)...))
-					}
-
-					for nidx, protoNested := range protoMessage.GetNestedType() {
-						p.appendMessage(cs.parseMessageNested(protoNested, protoMessage.GetName(), append(paths, COMMENT_PATH_MESSAGE_MESSAGE, nidx)...))
-					}
-
-					p.appendMessage(cs.parseMessage(protoMessage, paths...))
-				}
-
-				// parse service
-				for idx, protoService := range file.GetService() {
-					p.Services = append(p.Services, cs.parseService(protoService, COMMENT_PATH_SERVICE, idx))
-				}
+		go func(fidx int, protoFile *descriptorpb.FileDescriptorProto) {
+			if !strings.HasPrefix(protoFile.GetPackage(), "google.protobuf") {
+				files[fidx] = parseFile(protoFile)
 			}
 
 			swg.Done()
-		}(req.GetProtoFile()[fidx])
+		}(fidx, req.GetProtoFile()[fidx])
 	}
 
 	swg.Wait()
 
-	return p.sort()
+	for _, f := range files {
+		if f == nil {
+			continue
+		}
+
+		for _, enum := range f.enums {
+			p.appendEnum(enum)
+		}
+		for _, message := range f.messages {
+			p.appendMessage(message)
+		}
+		p.Services = append(p.Services, f.services...)
+	}
+
+	return p.hide().filter().sort()
+}
+
+// file proto 文件解析结果
+type file struct {
+	enums    []*Enum
+	messages []*Message
+	services []*Service
+}
+
+// parseFile parse proto file
+func parseFile(protoFile *descriptorpb.FileDescriptorProto) *file {
+	var f = new(file)
+
+	// parse comment
+	var cs = parseComments(protoFile.GetName(), protoFile.SourceCodeInfo)
+
+	// parse enum
+	for idx, protoEnum := range protoFile.GetEnumType() {
+		f.enums = append(f.enums, cs.parseEnum(protoEnum, COMMENT_PATH_ENUM, idx))
+	}
+
+	// parse message
+	for midx, protoMessage := range protoFile.GetMessageType() {
+		var paths = []int{COMMENT_PATH_MESSAGE, midx}
+
+		for eidx, protoEnum := range protoMessage.GetEnumType() {
+			f.enums = append(f.enums, cs.parseMessageEnum(protoEnum, protoMessage.GetName(), append(paths, COMMENT_PATH_MESSAGE_ENUM, eidx)...))
+		}
+
+		for nidx, protoNested := range protoMessage.GetNestedType() {
+			f.messages = append(f.messages, cs.parseMessageNested(protoNested, protoMessage.GetName(), append(paths, COMMENT_PATH_MESSAGE_MESSAGE, nidx)...))
+		}
+
+		f.messages = append(f.messages, cs.parseMessage(protoMessage, paths...))
+	}
+
+	// parse service
+	for idx, protoService := range protoFile.GetService() {
+		f.services = append(f.services, cs.parseService(protoService, COMMENT_PATH_SERVICE, idx))
+	}
+	return f
 }
 
 // parseComments paarse comments in proto
-func parseComments(infor *descriptorpb.SourceCodeInfo) comments {
+func parseComments(filename string, infor *descriptorpb.SourceCodeInfo) comments {
 	cs := make(map[string]*comment, 0)
+	cs[fmt.Sprintf("%v", []int{})] = &comment{position: Position{File: filename}}
 
 	for _, location := range infor.GetLocation() {
+		var path = fmt.Sprintf("%v", location.GetPath())
+
+		// declaration position
+		var position = Position{File: filename}
+		if span := location.GetSpan(); len(span) >= 2 {
+			position.Line = int(span[0]) + 1
+			position.Column = int(span[1]) + 1
+		}
+
 		if location.GetLeadingComments() == "" && location.GetTrailingComments() == "" && len(location.GetLeadingDetachedComments()) == 0 {
+			if _, found := cs[path]; !found {
+				cs[path] = &comment{position: position}
+			}
 			continue
 		}
 
@@ -120,10 +267,21 @@ func parseComments(infor *descriptorpb.SourceCodeInfo) comments {
 			detached = append(detached, trim(val, "*", "\n"))
 		}
 
-		cs[fmt.Sprintf("%v", location.GetPath())] = &comment{
-			leading:  trim(location.GetLeadingComments(), "*", "\n"),
-			trailing: trim(location.GetTrailingComments(), "*", "\n"),
-			detached: detached,
+		// leading comments 位于声明之前
+		var leadingPosition = Position{File: filename}
+		if position.Line != 0 {
+			leadingPosition.Line = position.Line - strings.Count(location.GetLeadingComments(), "\n")
+		}
+
+		leading, directives := parseDirectives(location.GetLeadingComments(), leadingPosition)
+		leading = trim(leading, "*", "\n")
+
+		cs[path] = &comment{
+			leading:    leading,
+			trailing:   trim(location.GetTrailingComments(), "*", "\n"),
+			detached:   detached,
+			directives: directives,
+			position:   position,
 		}
 	}
 	return cs
@@ -152,6 +310,9 @@ func (p *Package) appendMessage(def *Message) {
 // parseservice parse service in proto
 func (cs comments) parseService(dsdp *descriptorpb.ServiceDescriptorProto, paths ...int) *Service {
 	var service = newService(dsdp.GetName(), cs.comment(dsdp.GetName(), paths...))
+	service.Position = cs.position(paths...)
+	service.Directives = cs.directives(paths...)
+	service.Hidden = hidden(service.Directives, parseVisibility(dsdp.GetOptions()))
 
 	// descriptorpb.ServiceOptions
 	// if opt := parseServiceOption(dsdp.GetOptions()); opt != nil {
@@ -171,8 +332,11 @@ func (cs comments) parseService(dsdp *descriptorpb.ServiceDescriptorProto, paths
 // parseMethod parse method in service
 func (cs comments) parseMethod(dmdp *descriptorpb.MethodDescriptorProto, paths ...int) *ServiceMethod {
 	var method = newServiceMethod(dmdp.GetName(), cs.comment(dmdp.GetName(), paths...))
-	method.RequestName = split(dmdp.GetInputType())[1]
-	method.ResponseName = split(dmdp.GetOutputType())[1]
+	method.Position = cs.position(paths...)
+	method.Directives = cs.directives(paths...)
+	method.Hidden = hidden(method.Directives, parseVisibility(dmdp.GetOptions()))
+	method.RequestName = split(dmdp.GetInputType(), method.Position)[1]
+	method.ResponseName = split(dmdp.GetOutputType(), method.Position)[1]
 
 	// descriptorpb.MethodOptions
 	if opt := parseMethodOptions(dmdp.GetOptions()); opt != nil {
@@ -207,6 +371,7 @@ func (cs comments) parseMethod(dmdp *descriptorpb.MethodDescriptorProto, paths .
 // parseMessage parse message in proto
 func (cs comments) parseMessage(protoMessage *descriptorpb.DescriptorProto, paths ...int) *Message {
 	var message = newMessage(protoMessage.GetName(), cs.comment(protoMessage.GetName(), paths...))
+	message.Position = cs.position(paths...)
 
 	for idx, field := range protoMessage.GetField() {
 		message.Fields = append(message.Fields, cs.parseMessageField(protoMessage, field, append(paths, COMMENT_PATH_MESSAGE_FIELD, idx)...))
@@ -218,6 +383,8 @@ func (cs comments) parseMessage(protoMessage *descriptorpb.DescriptorProto, path
 func (cs comments) parseMessageNested(nested *descriptorpb.DescriptorProto, parent string, paths ...int) *Message {
 	name := nestedName(parent, nested.GetName())
 	var message = newMessage(name, cs.comment(name, paths...))
+	message.Position = cs.position(paths...)
+	message.Entry = nested.GetOptions().GetMapEntry()
 
 	for idx, field := range nested.GetField() {
 		message.Fields = append(message.Fields, cs.parseMessageField(nested, field, append(paths, COMMENT_PATH_MESSAGE_FIELD, idx)...))
@@ -229,6 +396,7 @@ func (cs comments) parseMessageNested(nested *descriptorpb.DescriptorProto, pare
 func (cs comments) parseMessageEnum(protoEnum *descriptorpb.EnumDescriptorProto, parent string, paths ...int) *Enum {
 	name := nestedName(parent, protoEnum.GetName())
 	var enum = newEnum(name, cs.comment(name, paths...))
+	enum.Position = cs.position(paths...)
 
 	for idx, enumField := range protoEnum.GetValue() {
 		enum.Fields = append(enum.Fields, cs.parseEnumField(enumField, append(paths, COMMENT_PATH_ENUM_VALUE, idx)...))
@@ -238,7 +406,7 @@ func (cs comments) parseMessageEnum(protoEnum *descriptorpb.EnumDescriptorProto,
 
 // parseMessageField parse field in message
 func (cs comments) parseMessageField(protoMessage *descriptorpb.DescriptorProto, protoField *descriptorpb.FieldDescriptorProto, paths ...int) *MessageField {
-	var field = &MessageField{MessageName: protoMessage.GetName(), Description: cs.comment(protoField.GetName(), paths...)}
+	var field = &MessageField{MessageName: protoMessage.GetName(), Description: cs.comment(protoField.GetName(), paths...), Position: cs.position(paths...), Directives: cs.directives(paths...)}
 
 	// Json
 	field.JsonName = protoField.GetName()
@@ -251,10 +419,25 @@ func (cs comments) parseMessageField(protoMessage *descriptorpb.DescriptorProto,
 	field.ProtoLaber = protoField.GetLabel()
 	field.ProtoType = protoField.GetType()
 	field.ProtoNumber = protoField.GetNumber()
+	field.Hidden = hidden(field.Directives, parseVisibility(protoField.GetOptions()))
+
+	// field behavior
+	field.Required = field.ProtoLaber == descriptorpb.FieldDescriptorProto_LABEL_REQUIRED || field.Directives.Has("required")
+	field.OutputOnly = field.Directives.Has("readonly")
+	for _, behavior := range parseFieldBehavior(protoField.GetOptions()) {
+		switch behavior {
+		case FieldBehaviorRequired:
+			field.Required = true
+		case FieldBehaviorOutputOnly:
+			field.OutputOnly = true
+		case FieldBehaviorInputOnly:
+			field.InputOnly = true
+		}
+	}
 
 	switch field.JsonType {
 	case JSON_TYPE_OBJECT:
-		typename := split(protoField.GetTypeName())
+		typename := split(protoField.GetTypeName(), field.Position)
 
 		field.ProtoTypeName = typename[1]
 		field.ProtoPackagePath = typename[0]
@@ -269,6 +452,7 @@ func (cs comments) parseMessageField(protoMessage *descriptorpb.DescriptorProto,
 // parseEnum parse enum in proto
 func (cs comments) parseEnum(protoEnum *descriptorpb.EnumDescriptorProto, paths ...int) *Enum {
 	var enum = newEnum(protoEnum.GetName(), cs.comment(protoEnum.GetName(), paths...))
+	enum.Position = cs.position(paths...)
 
 	for idx, enumField := range protoEnum.GetValue() {
 		enum.Fields = append(enum.Fields, cs.parseEnumField(enumField, append(paths, COMMENT_PATH_ENUM_VALUE, idx)...))
@@ -278,29 +462,13 @@ func (cs comments) parseEnum(protoEnum *descriptorpb.EnumDescriptorProto, paths
 
 // parseEnumField parse field in enum
 func (cs comments) parseEnumField(protoEnumField *descriptorpb.EnumValueDescriptorProto, paths ...int) *EnumField {
-	return &EnumField{
+	var field = &EnumField{
 		Name:        protoEnumField.GetName(),
 		Value:       protoEnumField.GetNumber(),
 		Description: cs.comment(protoEnumField.GetName(), paths...),
+		Position:    cs.position(paths...),
+		Directives:  cs.directives(paths...),
 	}
-}
-
-// parseMethodOptions .
-func parseMethodOptions(opts *descriptorpb.MethodOptions) *httppb.Http {
-	if opts != nil {
-		if exp, ok := proto.GetExtension(opts, httppb.E_Http).(*httppb.Http); ok {
-			return exp
-		}
-	}
-	return nil
-}
-
-// parseServiceOption .
-func parseServiceOption(opts *descriptorpb.ServiceOptions) *servicepb.Service {
-	if opts != nil {
-		if exp, ok := proto.GetExtension(opts, httppb.E_Http).(*servicepb.Service); ok {
-			return exp
-		}
-	}
-	return nil
+	field.Hidden = hidden(field.Directives, parseVisibility(protoEnumField.GetOptions()))
+	return field
 }
@@ -4,6 +4,9 @@ import (
 	"sort"
 	"sync"
 
+	"github.com/charlesbases/protoc-gen-swagger/conf"
+	"github.com/charlesbases/protoc-gen-swagger/diagnostic"
+
 	"google.golang.org/protobuf/types/descriptorpb"
 )
 
@@ -107,11 +110,21 @@ type (
 		Messages []*Message
 		// MessageDic Message map
 		MessageDic map[string]*Message
+		// Files 需要生成的 proto 文件. map[filename]bool
+		Files map[string]bool
+		// Removed 被移除的 service、rpc、字段所引用的 message 与 enum. 未被引用时不出现在文档中
+		Removed map[string]bool
 	}
 
 	Service struct {
 		Name        string
 		Description string
+		// Position declaration position
+		Position Position
+		// Directives directives in comment
+		Directives Directives
+		// Hidden 不出现在文档中
+		Hidden bool
 		// Methods rpc list
 		Methods []*ServiceMethod
 	}
@@ -126,24 +139,42 @@ type (
 		Produce      string
 		RequestName  string
 		ResponseName string
+		// Position declaration position
+		Position Position
+		// Directives directives in comment
+		Directives Directives
+		// Hidden 不出现在文档中
+		Hidden bool
 	}
 
 	Enum struct {
 		Name        string
 		Description string
-		Fields      []*EnumField
+		// Position declaration position
+		Position Position
+		Fields   []*EnumField
 	}
 
 	EnumField struct {
 		Name        string
 		Value       int32
 		Description string
+		// Position declaration position
+		Position Position
+		// Directives directives in comment
+		Directives Directives
+		// Hidden 不出现在文档中
+		Hidden bool
 	}
 
 	Message struct {
 		Name        string
 		Description string
-		Fields      []*MessageField
+		// Position declaration position
+		Position Position
+		// Entry proto 自动创建的 map entry message. 例：map<string, string>
+		Entry  bool
+		Fields []*MessageField
 	}
 
 	MessageField struct {
@@ -151,6 +182,12 @@ type (
 		MessageName string
 		// Description field description
 		Description string
+		// Position declaration position
+		Position Position
+		// Directives directives in comment
+		Directives Directives
+		// Hidden 不出现在文档中
+		Hidden bool
 
 		ProtoName        string                                  // proto field name
 		ProtoLaber       descriptorpb.FieldDescriptorProto_Label // proto 标签
@@ -160,6 +197,13 @@ type (
 		ProtoPackagePath string                                  // 包路径
 		ProtoNumber      int32                                   // 排序
 
+		// Required proto2 required, google.api.field_behavior = REQUIRED 或 @required
+		Required bool
+		// OutputOnly google.api.field_behavior = OUTPUT_ONLY 或 @readonly. 不出现在请求参数中
+		OutputOnly bool
+		// InputOnly google.api.field_behavior = INPUT_ONLY. 不出现在响应中
+		InputOnly bool
+
 		JsonName         string      // json field name
 		JsonLabel        string      // json 标签
 		JsonType         string      // json 类型
@@ -167,8 +211,171 @@ type (
 	}
 )
 
-// sort .
+// Position source position. e.g. users.proto:12:3
+type Position = diagnostic.Position
+
+// Directive 注释指令. e.g. // @payload file
+type Directive struct {
+	Name  string
+	Value string
+	// Block code block after directive
+	Block string
+	// Position directive position
+	Position Position
+}
+
+// Directives directive list
+type Directives []*Directive
+
+// Lookup 获取指令. 多个同名指令时返回第一个
+func (ds Directives) Lookup(name string) (string, bool) {
+	for _, d := range ds {
+		if d.Name == name {
+			return d.Value, true
+		}
+	}
+	return "", false
+}
+
+// Values 获取所有同名指令的值
+func (ds Directives) Values(name string) []string {
+	var values = make([]string, 0)
+	for _, d := range ds {
+		if d.Name == name {
+			values = append(values, d.Value)
+		}
+	}
+	return values
+}
+
+// Filter 获取所有同名指令
+func (ds Directives) Filter(name string) Directives {
+	var filtered = make(Directives, 0)
+	for _, d := range ds {
+		if d.Name == name {
+			filtered = append(filtered, d)
+		}
+	}
+	return filtered
+}
+
+// Has 是否存在指令
+func (ds Directives) Has(names ...string) bool {
+	for _, name := range names {
+		if _, found := ds.Lookup(name); found {
+			return true
+		}
+	}
+	return false
+}
+
+// VisibilityInternal 内部文档, 仅隐藏 @hidden
+const VisibilityInternal = "internal"
+
+// hidden 是否不出现在文档中. @hidden 始终隐藏; @internal 与 google.api visibility restriction 仅在 visibility=internal 时可见
+func hidden(ds Directives, restriction string) bool {
+	if ds.Has("hidden") {
+		return true
+	}
+	if conf.Get().Visibility == VisibilityInternal {
+		return false
+	}
+	return ds.Has("internal") || len(restriction) != 0
+}
+
+// hide 移除隐藏的 service、rpc、字段与枚举值
+func (p *Package) hide() *Package {
+	var services = make([]*Service, 0, len(p.Services))
+	for _, srv := range p.Services {
+		var methods = make([]*ServiceMethod, 0, len(srv.Methods))
+		for _, m := range srv.Methods {
+			if srv.Hidden || m.Hidden {
+				p.remove(m)
+				continue
+			}
+			methods = append(methods, m)
+		}
+		srv.Methods = methods
+
+		if !srv.Hidden {
+			services = append(services, srv)
+		}
+	}
+	p.Services = services
+
+	for _, mess := range p.Messages {
+		var fields = make([]*MessageField, 0, len(mess.Fields))
+		for _, field := range mess.Fields {
+			if field.Hidden {
+				if field.JsonType == JSON_TYPE_OBJECT {
+					p.Removed[field.ProtoTypeName] = true
+				}
+				continue
+			}
+			fields = append(fields, field)
+		}
+		mess.Fields = fields
+	}
+
+	for _, enum := range p.Enums {
+		var fields = make([]*EnumField, 0, len(enum.Fields))
+		for _, field := range enum.Fields {
+			if !field.Hidden {
+				fields = append(fields, field)
+			}
+		}
+		enum.Fields = fields
+	}
+	return p
+}
+
+// filter 根据 include 与 exclude 过滤 service 与 rpc. 匹配 service 名称或 "service.rpc"
+func (p *Package) filter() *Package {
+	var include, exclude = conf.Get().Filter.Include, conf.Get().Filter.Exclude
+	if len(include) == 0 && len(exclude) == 0 {
+		return p
+	}
+
+	var services = make([]*Service, 0, len(p.Services))
+	for _, srv := range p.Services {
+		var methods = make([]*ServiceMethod, 0, len(srv.Methods))
+		for _, m := range srv.Methods {
+			if !Matches(include, exclude, srv.Name, srv.Name+"."+m.Name) {
+				p.remove(m)
+				continue
+			}
+			methods = append(methods, m)
+		}
+
+		if len(methods) != 0 || len(srv.Methods) == 0 && Matches(include, exclude, srv.Name) {
+			srv.Methods = methods
+			services = append(services, srv)
+		}
+	}
+	p.Services = services
+	return p
+}
+
+// remove 记录被移除的 rpc 所引用的 message
+func (p *Package) remove(m *ServiceMethod) {
+	p.Removed[m.RequestName] = true
+	p.Removed[m.ResponseName] = true
+}
+
+// order
+const (
+	// OrderDeclaration proto 声明顺序, 默认值
+	OrderDeclaration = "declaration"
+	// OrderAlphabetical 字母顺序
+	OrderAlphabetical = "alphabetical"
+)
+
+// sort 按字母顺序排序 service、message 与 enum. 默认保持声明顺序
 func (p *Package) sort() *Package {
+	if conf.Get().Order != OrderAlphabetical {
+		return p
+	}
+
 	var swg = sync.WaitGroup{}
 	swg.Add(3)
 
@@ -1,11 +1,15 @@
 package protoc
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"strings"
-	"time"
 
-	"github.com/charlesbases/protoc-gen-swagger/logger"
+	"github.com/charlesbases/protoc-gen-swagger/conf"
+	"github.com/charlesbases/protoc-gen-swagger/diagnostic"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
 )
 
 // trim  prefix and suffix TODO 可优化
@@ -31,11 +35,12 @@ func trim(source string, cutsets ...string) string {
 	return source
 }
 
-// split split by "." and return package and type name
-func split(typename string) [2]string {
+// split split by "." and return package and type name. e.g. .pb.Request
+func split(typename string, position Position) [2]string {
 	list := strings.Split(typename, ".")
 	if len(list) < 3 {
-		logger.Fatal("split type failed. ", typename)
+		diagnostic.Errorf(position, "invalid type name %q, package is required", typename)
+		return [2]string{}
 	}
 	return [2]string{list[1], strings.Join(list[2:], "_")}
 }
@@ -50,9 +55,100 @@ func methodPath(v ...string) string {
 	return "/" + strings.Join(v, "/")
 }
 
-// version .
-func version() string {
-	return time.Now().Format("20060102150405")
+// boolean 解析 bool 参数. 参数值为空时为 true. e.g. example, example=true
+func boolean(value string) bool {
+	switch strings.ToLower(value) {
+	case "", "true", "1", "on", "yes":
+		return true
+	default:
+		return false
+	}
+}
+
+// Match glob 匹配. "*" 匹配任意字符, "?" 匹配单个字符. e.g. Users.*, /api/v1/*
+func Match(pattern, name string) bool {
+	for len(pattern) != 0 {
+		switch pattern[0] {
+		case '*':
+			pattern = strings.TrimLeft(pattern, "*")
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := 0; i <= len(name); i++ {
+				if Match(pattern, name[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(name) == 0 {
+				return false
+			}
+		default:
+			if len(name) == 0 || pattern[0] != name[0] {
+				return false
+			}
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// Matches 是否匹配 include 且不匹配 exclude. include 为空时全部匹配
+func Matches(include []string, exclude []string, names ...string) bool {
+	var matched = func(patterns []string) bool {
+		for _, pattern := range patterns {
+			for _, name := range names {
+				if Match(pattern, name) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	if matched(exclude) {
+		return false
+	}
+	return len(include) == 0 || matched(include)
+}
+
+// versionHashLength 内容 hash 版本长度
+const versionHashLength = 12
+
+// version 文档版本. 优先级: 参数 version 或 swagger.toml, proto 文件 openapiv2_swagger option 中的 info.version, proto 文件内容 hash
+func version(req *pluginpb.CodeGeneratorRequest) string {
+	if len(conf.Get().Version) != 0 {
+		return conf.Get().Version
+	}
+
+	var generate = make(map[string]bool, len(req.GetFileToGenerate()))
+	for _, name := range req.GetFileToGenerate() {
+		generate[name] = true
+	}
+	for _, file := range req.GetProtoFile() {
+		if generate[file.GetName()] {
+			if v := parseFileVersion(file.GetOptions()); len(v) != 0 {
+				return v
+			}
+		}
+	}
+
+	// 相同 proto 文件生成相同版本
+	var hash = sha256.New()
+	for _, file := range req.GetProtoFile() {
+		if strings.HasPrefix(file.GetPackage(), "google.protobuf") {
+			continue
+		}
+
+		data, err := proto.MarshalOptions{Deterministic: true}.Marshal(file)
+		if err != nil {
+			diagnostic.Errorf(Position{File: file.GetName()}, "marshal proto file failed. %v", err)
+			continue
+		}
+		hash.Write(data)
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:versionHashLength]
 }
 
 // ascending 升序
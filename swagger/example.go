@@ -0,0 +1,383 @@
+package swagger
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charlesbases/protoc-gen-swagger/conf"
+	"github.com/charlesbases/protoc-gen-swagger/diagnostic"
+	"github.com/charlesbases/protoc-gen-swagger/protoc"
+)
+
+// ExampleDepth 示例中嵌套 message 最大层数
+const ExampleDepth = 5
+
+// parseExamples 生成 Definition 与内联 body 结构示例
+func (s *Swagger) parseExamples() {
+	for name, def := range s.Definitions {
+		def.Example = s.example(name, def, nil, map[string]bool{name: true}, ExampleDepth)
+	}
+
+	s.Paths.Range(func(uri string, method string, api *API) {
+		for _, parameter := range api.Parameters {
+			// $ref 使用 Definition 示例, 内联结构 (移除 path 参数或 OUTPUT_ONLY 字段后的副本) 在 schema 中附加示例
+			if parameter.In == PositionBody && parameter.Schema != nil && len(parameter.Schema.Reflex) == 0 && parameter.Schema.Example == nil {
+				parameter.Schema.Example = s.example(parameter.Name, parameter.Schema, nil, make(map[string]bool, 0), ExampleDepth)
+			}
+		}
+	})
+}
+
+// example 生成示例. mf 为 Definition 对应的 message 字段, 用于默认值
+func (s *Swagger) example(name string, def *Definition, mf *protoc.MessageField, visited map[string]bool, depth int) interface{} {
+	// reference
+	if len(def.Reflex) != 0 {
+		var refname = strings.TrimPrefix(def.Reflex, refprefix)
+		ref, found := s.Definitions[refname]
+		if !found || visited[refname] || depth <= 0 {
+			return nil
+		}
+
+		visited[refname] = true
+		defer delete(visited, refname)
+		return s.example(name, ref, mf, visited, depth-1)
+	}
+
+	// enum
+	if len(def.Enum) != 0 {
+		return s.enumExample(def)
+	}
+
+	switch def.Type {
+	case "array":
+		if def.Items == nil {
+			return []interface{}{}
+		}
+		if item := s.example(name, def.Items, mf, visited, depth); item != nil {
+			return []interface{}{item}
+		}
+		return []interface{}{}
+	case "object", "":
+		var example = newObject(def.Nesteds.Len())
+		if def.Entry != nil {
+			if value := s.example(name, def.Entry, nil, visited, depth); value != nil {
+				example.set("key", value)
+			}
+		}
+
+		var message = s.p.MessageDic[def.Name]
+		for _, field := range def.Nesteds.Keys() {
+			nested, _ := def.Nesteds.Get(field)
+			if value := s.example(field, nested, messageField(message, field), visited, depth); value != nil {
+				example.set(field, value)
+			}
+		}
+		return example
+	default:
+		return scalarExample(name, def, mf)
+	}
+}
+
+// enumExample 枚举示例. 优先使用第一个非零值
+func (s *Swagger) enumExample(def *Definition) interface{} {
+	if enum, found := s.p.EnumDic[def.Name]; found {
+		for _, field := range enum.Fields {
+			if field.Value != 0 {
+				if conf.Get().Enum == EnumNumber {
+					return field.Value
+				}
+				return field.Name
+			}
+		}
+	}
+	return def.Enum[0]
+}
+
+// messageField .
+func messageField(message *protoc.Message, name string) *protoc.MessageField {
+	if message != nil {
+		for _, mf := range message.Fields {
+			if mf.ProtoName == name {
+				return mf
+			}
+		}
+	}
+	return nil
+}
+
+// exampleTime 时间示例
+const exampleTime = "2006-01-02T15:04:05Z"
+
+// scalarExample 根据字段名生成示例. e.g. email, phone, id, url, time
+func scalarExample(name string, def *Definition, mf *protoc.MessageField) interface{} {
+	var lower = strings.ToLower(name)
+	var has = func(keys ...string) bool {
+		for _, key := range keys {
+			if strings.Contains(lower, key) {
+				return true
+			}
+		}
+		return false
+	}
+	var suffix = func(keys ...string) bool {
+		for _, key := range keys {
+			if strings.HasSuffix(lower, key) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch def.Type {
+	case "string":
+		switch {
+		case def.Format == "byte":
+			return "ZXhhbXBsZQ=="
+		case def.Format == "int64", def.Format == "uint64":
+			if suffix("id", "ids") {
+				return "1"
+			}
+			return "0"
+		case has("email", "mail"):
+			return "user@example.com"
+		case has("phone", "mobile", "tel"):
+			return "13800138000"
+		case has("url", "link", "avatar", "image"):
+			return "https://example.com"
+		case has("time", "date") || suffix("_at"):
+			return exampleTime
+		case suffix("uuid"):
+			return "00000000-0000-0000-0000-000000000001"
+		case suffix("id", "ids"):
+			return "1"
+		case has("name"):
+			return "name"
+		}
+	case "integer":
+		switch {
+		case suffix("id", "ids"):
+			return 1
+		case has("time", "date") || suffix("_at"):
+			return 1136214245
+		case has("page", "num"):
+			return 1
+		case has("size", "limit"):
+			return 20
+		}
+	}
+
+	// default
+	if mf != nil && mf.JsonDefaultValue != nil {
+		return mf.JsonDefaultValue
+	}
+	switch def.Type {
+	case "string":
+		return "string"
+	case "integer", "number":
+		return 0
+	case "boolean":
+		return false
+	default:
+		return nil
+	}
+}
+
+// parseExampleDirectives 解析 rpc 注释中的示例. e.g.
+//
+//	// @example request
+//	// ```json
+//	// {"id": 1}
+//	// ```
+func (api *API) parseExampleDirectives(s *Swagger, m *protoc.ServiceMethod) {
+	for _, directive := range m.Directives {
+		if directive.Name != "example" {
+			continue
+		}
+
+		var value interface{}
+		if err := json.Unmarshal([]byte(directive.Block), &value); err != nil {
+			diagnostic.Warnf(directive.Position, "invalid @example %s. %v", directive.Value, err)
+			continue
+		}
+
+		switch directive.Value {
+		case "request":
+			var body *Parameter
+			for _, parameter := range api.Parameters {
+				if parameter.In == PositionBody {
+					body = parameter
+				}
+			}
+			if body == nil {
+				diagnostic.Warnf(directive.Position, "@example request ignored, %s [%s] has no request body", m.Path, m.Method)
+				continue
+			}
+
+			s.validateExample(directive, body.Schema, value)
+
+			// $ref 无法附加 example, 使用 Definition 副本
+			if len(body.Schema.Reflex) != 0 {
+				if def, found := s.Definitions[strings.TrimPrefix(body.Schema.Reflex, refprefix)]; found {
+					var copied = *def
+					body.Schema = &copied
+				}
+			}
+			body.Schema.Example = value
+		case "response":
+			var rsp = api.Responses["200"]
+			if rsp == nil || rsp.Schema == nil || rsp.Schema.Type == "file" {
+				diagnostic.Warnf(directive.Position, "@example response ignored, %s [%s] has no response body", m.Path, m.Method)
+				continue
+			}
+
+			s.validateExample(directive, rsp.Schema, value)
+
+			rsp.Examples = map[string]interface{}{m.Produce: value}
+		default:
+			diagnostic.Warnf(directive.Position, "invalid directive: @example %s. request or response", directive.Value)
+		}
+	}
+}
+
+// validateExample 校验示例并输出不匹配项
+func (s *Swagger) validateExample(directive *protoc.Directive, def *Definition, value interface{}) {
+	for _, mismatch := range s.validate("$", def, value) {
+		diagnostic.Warnf(directive.Position, "@example %s: %s", directive.Value, mismatch)
+	}
+}
+
+// validate 校验示例是否符合 Definition, 返回不匹配项
+func (s *Swagger) validate(path string, def *Definition, value interface{}) []string {
+	if value == nil {
+		return nil
+	}
+
+	// reference
+	if len(def.Reflex) != 0 {
+		if ref, found := s.Definitions[strings.TrimPrefix(def.Reflex, refprefix)]; found {
+			return s.validate(path, ref, value)
+		}
+		return nil
+	}
+
+	var mismatch = func(expected string) []string {
+		return []string{fmt.Sprintf("%s: expected %s, got %s", path, expected, jsonKind(value))}
+	}
+
+	// enum. protojson 中名称与数值均可
+	if len(def.Enum) != 0 {
+		switch v := value.(type) {
+		case string, float64:
+			if enum, found := s.p.EnumDic[def.Name]; found {
+				for _, field := range enum.Fields {
+					if field.Name == v || float64(field.Value) == v {
+						return nil
+					}
+				}
+			}
+			for _, e := range def.Enum {
+				if fmt.Sprint(e) == fmt.Sprint(v) {
+					return nil
+				}
+			}
+			return []string{fmt.Sprintf("%s: unknown enum value %v", path, v)}
+		default:
+			return mismatch("enum")
+		}
+	}
+
+	switch def.Type {
+	case "array":
+		list, ok := value.([]interface{})
+		if !ok {
+			return mismatch("array")
+		}
+
+		var mismatches = make([]string, 0)
+		if def.Items != nil {
+			for idx, item := range list {
+				mismatches = append(mismatches, s.validate(path+"["+strconv.Itoa(idx)+"]", def.Items, item)...)
+			}
+		}
+		return mismatches
+	case "object", "":
+		if def.Entry == nil && def.Nesteds.Len() == 0 && def.Type == "" {
+			return nil
+		}
+
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return mismatch("object")
+		}
+
+		var keys = make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		var mismatches = make([]string, 0)
+		for _, key := range keys {
+			switch nested, found := def.Nesteds.Get(key); {
+			case found:
+				mismatches = append(mismatches, s.validate(path+"."+key, nested, object[key])...)
+			case def.Entry != nil:
+				mismatches = append(mismatches, s.validate(path+"."+key, def.Entry, object[key])...)
+			default:
+				mismatches = append(mismatches, fmt.Sprintf("%s: unknown field %q", path, key))
+			}
+		}
+		return mismatches
+	case "string":
+		if _, ok := value.(string); !ok {
+			return mismatch("string")
+		}
+	case "integer":
+		switch v := value.(type) {
+		case float64:
+			if v != math.Trunc(v) {
+				return mismatch("integer")
+			}
+		case string:
+			// protojson 中 64 位整数为字符串
+			if _, err := strconv.ParseFloat(v, 64); err != nil {
+				return mismatch("integer")
+			}
+		default:
+			return mismatch("integer")
+		}
+	case "number":
+		switch value.(type) {
+		case float64, string:
+		default:
+			return mismatch("number")
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return mismatch("boolean")
+		}
+	}
+	return nil
+}
+
+// jsonKind json value type
+func jsonKind(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return "null"
+	}
+}
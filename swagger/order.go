@@ -0,0 +1,224 @@
+package swagger
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+
+	"github.com/charlesbases/protoc-gen-swagger/conf"
+	"github.com/charlesbases/protoc-gen-swagger/protoc"
+)
+
+// alphabetical order=alphabetical
+func alphabetical() bool {
+	return conf.Get().Order == protoc.OrderAlphabetical
+}
+
+// ordered 输出顺序. 默认为声明顺序, order=alphabetical 时为字母顺序
+func ordered(keys []string) []string {
+	if !alphabetical() {
+		return keys
+	}
+
+	var sorted = make([]string, len(keys))
+	copy(sorted, keys)
+	sort.Strings(sorted)
+	return sorted
+}
+
+// marshalObject 按 keys 顺序输出 json object. encoding/json 中 map 按 key 排序输出
+func marshalObject(keys []string, value func(key string) interface{}) ([]byte, error) {
+	var buff = new(bytes.Buffer)
+	buff.WriteByte('{')
+	for idx, key := range keys {
+		if idx != 0 {
+			buff.WriteByte(',')
+		}
+
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(value(key))
+		if err != nil {
+			return nil, err
+		}
+
+		buff.Write(k)
+		buff.WriteByte(':')
+		buff.Write(v)
+	}
+	buff.WriteByte('}')
+	return buff.Bytes(), nil
+}
+
+// object 有序 json object. 用于示例
+type object struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// newObject .
+func newObject(size int) *object {
+	return &object{
+		keys:   make([]string, 0, size),
+		values: make(map[string]interface{}, size),
+	}
+}
+
+// set 追加或覆盖 key. 覆盖时保持原有顺序
+func (o *object) set(key string, value interface{}) {
+	if _, found := o.values[key]; !found {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+// MarshalJSON .
+func (o *object) MarshalJSON() ([]byte, error) {
+	return marshalObject(ordered(o.keys), func(key string) interface{} {
+		return o.values[key]
+	})
+}
+
+// Properties Definition 属性. 保持 message 字段声明顺序
+type Properties struct {
+	keys   []string
+	values map[string]*Definition
+}
+
+// Len .
+func (ps *Properties) Len() int {
+	if ps == nil {
+		return 0
+	}
+	return len(ps.keys)
+}
+
+// Keys 属性名, 声明顺序
+func (ps *Properties) Keys() []string {
+	if ps == nil {
+		return nil
+	}
+	return ps.keys
+}
+
+// Get .
+func (ps *Properties) Get(name string) (*Definition, bool) {
+	if ps == nil {
+		return nil, false
+	}
+	def, found := ps.values[name]
+	return def, found
+}
+
+// Delete .
+func (ps *Properties) Delete(name string) {
+	if _, found := ps.Get(name); !found {
+		return
+	}
+
+	delete(ps.values, name)
+	for idx, key := range ps.keys {
+		if key == name {
+			ps.keys = append(ps.keys[:idx:idx], ps.keys[idx+1:]...)
+			break
+		}
+	}
+}
+
+// copy .
+func (ps *Properties) copy() *Properties {
+	var copied = &Properties{
+		keys:   make([]string, 0, ps.Len()),
+		values: make(map[string]*Definition, ps.Len()),
+	}
+	for _, key := range ps.Keys() {
+		copied.keys = append(copied.keys, key)
+		copied.values[key] = ps.values[key]
+	}
+	return copied
+}
+
+// MarshalJSON .
+func (ps *Properties) MarshalJSON() ([]byte, error) {
+	return marshalObject(ordered(ps.Keys()), func(key string) interface{} {
+		return ps.values[key]
+	})
+}
+
+// property 追加或覆盖 Definition 属性. 覆盖时保持原有顺序
+func (d *Definition) property(name string, def *Definition) {
+	if d.Nesteds == nil {
+		d.Nesteds = &Properties{values: make(map[string]*Definition, 0)}
+	}
+	if _, found := d.Nesteds.values[name]; !found {
+		d.Nesteds.keys = append(d.Nesteds.keys, name)
+	}
+	d.Nesteds.values[name] = def
+}
+
+// Paths api 列表. 保持 rpc 声明顺序
+type Paths struct {
+	uris []string
+	// methods map[uri][]method
+	methods map[string][]string
+	// apis map[uri][method]*API
+	apis map[string]map[string]*API
+}
+
+// newPaths .
+func newPaths() *Paths {
+	return &Paths{
+		uris:    make([]string, 0),
+		methods: make(map[string][]string, 0),
+		apis:    make(map[string]map[string]*API, 0),
+	}
+}
+
+// Get .
+func (ps *Paths) Get(uri string, method string) (*API, bool) {
+	api, found := ps.apis[uri][method]
+	return api, found
+}
+
+// set 追加 api
+func (ps *Paths) set(uri string, method string, api *API) {
+	if _, found := ps.apis[uri]; !found {
+		ps.uris = append(ps.uris, uri)
+		ps.apis[uri] = make(map[string]*API, 0)
+	}
+	if _, found := ps.apis[uri][method]; !found {
+		ps.methods[uri] = append(ps.methods[uri], method)
+	}
+	ps.apis[uri][method] = api
+}
+
+// Range 按声明顺序遍历 api
+func (ps *Paths) Range(fn func(uri string, method string, api *API)) {
+	for _, uri := range ps.uris {
+		for _, method := range ps.methods[uri] {
+			fn(uri, method, ps.apis[uri][method])
+		}
+	}
+}
+
+// MarshalJSON .
+func (ps *Paths) MarshalJSON() ([]byte, error) {
+	return marshalObject(ordered(ps.uris), func(uri string) interface{} {
+		return &pathItem{methods: ps.methods[uri], apis: ps.apis[uri]}
+	})
+}
+
+// pathItem uri 下的 api 列表
+type pathItem struct {
+	methods []string
+	apis    map[string]*API
+}
+
+// MarshalJSON .
+func (pi *pathItem) MarshalJSON() ([]byte, error) {
+	return marshalObject(ordered(pi.methods), func(method string) interface{} {
+		return pi.apis[method]
+	})
+}
@@ -0,0 +1,79 @@
+package swagger
+
+import (
+	"strings"
+
+	"github.com/charlesbases/protoc-gen-swagger/conf"
+	"github.com/charlesbases/protoc-gen-swagger/diagnostic"
+	"github.com/charlesbases/protoc-gen-swagger/protoc"
+)
+
+// exclude 复制 Definition 并移除指定字段. 用于移除 body 中已在 path 中声明的参数
+func (s *Swagger) exclude(def *Definition, fields [][]string) *Definition {
+	var copied = *def
+	copied.Nesteds = def.Nesteds.copy()
+
+	var nesteds = make(map[string][][]string, 0)
+	for _, field := range fields {
+		switch len(field) {
+		case 0:
+		case 1:
+			copied.Nesteds.Delete(field[0])
+		default:
+			nesteds[field[0]] = append(nesteds[field[0]], field[1:])
+		}
+	}
+
+	// required
+	if len(def.Required) != 0 {
+		copied.Required = make([]string, 0, len(def.Required))
+		for _, name := range def.Required {
+			if _, found := copied.Nesteds.Get(name); found {
+				copied.Required = append(copied.Required, name)
+			}
+		}
+	}
+
+	for name, subfields := range nesteds {
+		if field, found := copied.Nesteds.Get(name); found && len(field.Reflex) != 0 {
+			if nested, found := s.Definitions[strings.TrimPrefix(field.Reflex, refprefix)]; found {
+				exclude := s.exclude(nested, subfields)
+				exclude.Description = field.Description
+				copied.property(name, exclude)
+			}
+		}
+	}
+
+	if copied.Nesteds.Len() == 0 {
+		copied.Nesteds = nil
+	}
+	return &copied
+}
+
+// parseParameterInPath .
+func (api *API) parseParameterInPath(s *Swagger, m *protoc.ServiceMethod, params []*protoc.PathParam) {
+	for _, param := range params {
+		mf := s.p.Field(m.RequestName, param.Fields())
+		if mf == nil {
+			// 启用 lint 时由 path_param 规则输出
+			if !conf.Get().Lint.Enabled {
+				diagnostic.Warnf(m.Position, "path parameter {%s} not found in %s. %s [%s]", param.Name, m.RequestName, m.Path, m.Method)
+			}
+
+			api.Parameters = append(api.Parameters, &Parameter{
+				In:       PositionPath,
+				Name:     param.Name,
+				Type:     "string",
+				Required: true,
+			})
+			continue
+		}
+
+		var parameter = s.parameter(PositionPath, param.Name, mf)
+		parameter.Required = true
+		if len(param.Pattern) != 0 {
+			parameter.Pattern = param.Regexp()
+		}
+		api.Parameters = append(api.Parameters, parameter)
+	}
+}
@@ -2,11 +2,15 @@ package swagger
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/charlesbases/protoc-gen-swagger/conf"
-	"github.com/charlesbases/protoc-gen-swagger/logger"
+	"github.com/charlesbases/protoc-gen-swagger/diagnostic"
 	"github.com/charlesbases/protoc-gen-swagger/protoc"
 	"google.golang.org/protobuf/types/descriptorpb"
 	"google.golang.org/protobuf/types/pluginpb"
@@ -16,6 +20,12 @@ const SwaggerVersion = "2.0"
 
 const DefaultAPIHost = "127.0.0.1"
 
+// DefaultOperationID operationId 默认模板
+const DefaultOperationID = "{service}_{method}"
+
+// DefaultQueryDepth GET 请求中嵌套 message 默认展开层数
+const DefaultQueryDepth = 3
+
 var DefaultSchemes = []string{"http", "https"}
 
 // apiHost .
@@ -26,6 +36,14 @@ func apiHost() string {
 	return DefaultAPIHost
 }
 
+// QueryDepth GET 请求中嵌套 message 展开层数
+func QueryDepth() int {
+	if conf.Get().Query.Depth > 0 {
+		return conf.Get().Query.Depth
+	}
+	return DefaultQueryDepth
+}
+
 // New .
 func New(p *protoc.Package) *Swagger {
 	var title = conf.Get().Title
@@ -37,6 +55,10 @@ func New(p *protoc.Package) *Swagger {
 		name: p.Name + ".json",
 		p:    p,
 
+		operations: make(map[string]bool, 0),
+		envelopes:  make(map[string]string, 0),
+		drops:      make(map[string]bool, 0),
+
 		Swagger: SwaggerVersion,
 		Info: &Info{
 			Title:       title,
@@ -46,12 +68,26 @@ func New(p *protoc.Package) *Swagger {
 		Host:     apiHost(),
 		BasePath: "",
 		Schemes:  DefaultSchemes,
-		Paths:    make(map[string]map[string]*API, 0),
+		Paths:    newPaths(),
 	}
 
 	s.parseDefinitions()
+	s.parseSecurityDefinitions()
+	validateHeaders()
 	s.parseServices()
 
+	// 移除未被 api 引用的 Definition. keep_unused 时仅移除被隐藏或过滤的 service、rpc、字段引用的 Definition
+	switch {
+	case !conf.Get().KeepUnused:
+		s.prune(nil)
+	case len(p.Removed) != 0:
+		s.prune(p.Removed)
+	}
+
+	if conf.Get().Example {
+		s.parseExamples()
+	}
+
 	return s
 }
 
@@ -59,7 +95,17 @@ func New(p *protoc.Package) *Swagger {
 func (s *Swagger) Generater() *pluginpb.CodeGeneratorResponse_File {
 	data, err := json.MarshalIndent(s, "", "  ")
 	if err != nil {
-		logger.Fatal(err)
+		diagnostic.Errorf(protoc.Position{}, "marshal %s failed. %v", s.name, err)
+		return nil
+	}
+
+	// yaml
+	if conf.Get().Format == FormatYAML {
+		if data, err = toYAML(data); err != nil {
+			diagnostic.Errorf(protoc.Position{}, "convert %s to yaml failed. %v", s.name, err)
+			return nil
+		}
+		s.name = strings.TrimSuffix(s.name, ".json") + ".yaml"
 	}
 
 	var content = string(data)
@@ -84,24 +130,52 @@ func (s *Swagger) parseServices() {
 
 		for _, m := range srv.Methods {
 			api := &API{
-				Tags:       []string{tag.Name},
-				Summary:    m.Description,
-				Consumes:   []string{m.Consume},
-				Produces:   []string{m.Produce},
-				Parameters: make([]*Parameter, 0),
-				Responses:  make(map[string]*Parameter),
+				rpc: m,
+
+				Tags:        []string{tag.Name},
+				Summary:     m.Description,
+				OperationID: s.operationID(srv, m),
+				Consumes:    []string{m.Consume},
+				Produces:    []string{m.Produce},
+				Parameters:  make([]*Parameter, 0),
+				Responses:   make(map[string]*Response),
 			}
 
-			api.parseResponses(s, m)
-			api.parseParameter(s, m)
+			uri, params := protoc.ParsePathTemplate(m.Path)
+
+			api.parseSecurity(s, srv, m)
+			api.parseResponses(s, srv, m)
+			api.parseParameter(s, srv, m, uri, params)
+			api.parseExampleDirectives(s, m)
 
-			s.push(m.Path, m.Method.LowerCase(), api)
+			s.push(uri, m.Method.LowerCase(), api)
 		}
 
 		s.Tags = append(s.Tags, tag)
 	}
 }
 
+// operationID 根据模板生成 operationId. 重复时按生成顺序追加序号
+func (s *Swagger) operationID(srv *protoc.Service, m *protoc.ServiceMethod) string {
+	var template = conf.Get().Operation.ID
+	if len(template) == 0 {
+		template = DefaultOperationID
+	}
+
+	var id = strings.NewReplacer(
+		"{package}", s.p.Name,
+		"{service}", srv.Name,
+		"{method}", m.Name,
+	).Replace(template)
+
+	var unique = id
+	for idx := 2; s.operations[unique]; idx++ {
+		unique = id + "_" + strconv.Itoa(idx)
+	}
+	s.operations[unique] = true
+	return unique
+}
+
 const refprefix = "#/definitions/"
 
 // parseDefinitions .
@@ -115,20 +189,61 @@ func (s *Swagger) parseDefinitions() {
 	for _, mess := range s.p.Messages {
 		s.parseProtoMessage(mess)
 	}
+
+	// parse error model
+	s.parseErrorDefinition()
 }
 
+// enum mode
+const (
+	// EnumName 枚举以名称表示, 默认值
+	EnumName = "name"
+	// EnumNumber 枚举以数值表示, 与 protojson UseEnumNumbers 一致
+	EnumNumber = "number"
+	// EnumBoth 枚举名称与数值均可
+	EnumBoth = "both"
+)
+
 // parseProtoEnum .
 func (s *Swagger) parseProtoEnum() {
 	for _, enum := range s.p.Enums {
 		var def = &Definition{
-			Name: enum.Name,
-			Type: "string",
-			Enum: make([]string, 0, len(enum.Fields)),
+			Name:             enum.Name,
+			Enum:             make([]interface{}, 0, len(enum.Fields)),
+			EnumVarnames:     make([]string, 0, len(enum.Fields)),
+			EnumDescriptions: make([]string, 0, len(enum.Fields)),
 		}
 
-		// key list
+		var prefix = enumPrefix(enum)
 		for _, field := range enum.Fields {
-			def.Enum = append(def.Enum, field.Name)
+			def.EnumVarnames = append(def.EnumVarnames, strings.TrimPrefix(field.Name, prefix))
+			def.EnumDescriptions = append(def.EnumDescriptions, enumFieldDescription(field))
+		}
+
+		// key list
+		switch conf.Get().Enum {
+		case EnumNumber:
+			def.Type = "integer"
+			def.Format = "int32"
+			for _, field := range enum.Fields {
+				def.Enum = append(def.Enum, field.Value)
+			}
+		case EnumBoth:
+			for _, field := range enum.Fields {
+				def.Enum = append(def.Enum, field.Name)
+			}
+			for _, field := range enum.Fields {
+				def.Enum = append(def.Enum, field.Value)
+			}
+
+			// 名称与数值类型不同, swagger 2.0 无法声明多个 type, 不设置 type. x-enum-varnames 与 enum 一一对应
+			def.EnumVarnames = append(def.EnumVarnames, def.EnumVarnames...)
+			def.EnumDescriptions = append(def.EnumDescriptions, def.EnumDescriptions...)
+		default:
+			def.Type = "string"
+			for _, field := range enum.Fields {
+				def.Enum = append(def.Enum, field.Name)
+			}
 		}
 
 		// default
@@ -136,13 +251,93 @@ func (s *Swagger) parseProtoEnum() {
 			def.Default = def.Enum[0]
 		}
 
-		// desc TODO enum desc + enum.field desc
-		def.Description = enum.Description
+		// 枚举值均无注释时不生成 x-enum-descriptions
+		if !described(enum) {
+			def.EnumDescriptions = nil
+		}
+
+		// desc
+		def.Description = enumDescription(enum)
+		if conf.Get().Enum == EnumBoth {
+			def.Description = strings.TrimSpace(def.Description + "\n\nAccepts the enum name (string) or number (integer).")
+		}
 
 		s.Definitions[enum.Name] = def
 	}
 }
 
+// described 是否存在有注释的枚举值
+func described(enum *protoc.Enum) bool {
+	for _, field := range enum.Fields {
+		if len(enumFieldDescription(field)) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// enumDescription 枚举描述与枚举值表格
+func enumDescription(enum *protoc.Enum) string {
+	if len(enum.Fields) == 0 {
+		return enum.Description
+	}
+
+	var bs strings.Builder
+	bs.WriteString(enum.Description)
+	bs.WriteString("\n\n| Value | Name | Description |\n| --- | --- | --- |\n")
+	for _, field := range enum.Fields {
+		bs.WriteString("| ")
+		bs.WriteString(strconv.Itoa(int(field.Value)))
+		bs.WriteString(" | ")
+		bs.WriteString(field.Name)
+		bs.WriteString(" | ")
+		bs.WriteString(strings.ReplaceAll(enumFieldDescription(field), "\n", " "))
+		bs.WriteString(" |\n")
+	}
+	return strings.TrimSuffix(bs.String(), "\n")
+}
+
+// enumFieldDescription 枚举值描述. 无注释时为空
+func enumFieldDescription(field *protoc.EnumField) string {
+	if field.Description == field.Name {
+		return ""
+	}
+	return field.Description
+}
+
+// enumPrefix strip_enum_prefix 时移除的枚举值前缀. 所有枚举值均包含前缀时有效. e.g. FileType => FILE_TYPE_
+func enumPrefix(enum *protoc.Enum) string {
+	if !conf.Get().StripEnumPrefix || len(enum.Fields) == 0 {
+		return ""
+	}
+
+	// nested enum. e.g. Parent_Child
+	var name = enum.Name
+	if i := strings.LastIndex(name, "_"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	var runes = []rune(name)
+	var bs strings.Builder
+	for idx, r := range runes {
+		if idx != 0 && unicode.IsUpper(r) {
+			if prev := runes[idx-1]; !unicode.IsUpper(prev) || idx+1 < len(runes) && unicode.IsLower(runes[idx+1]) {
+				bs.WriteByte('_')
+			}
+		}
+		bs.WriteRune(unicode.ToUpper(r))
+	}
+	bs.WriteByte('_')
+
+	var prefix = bs.String()
+	for _, field := range enum.Fields {
+		if trimmed := strings.TrimPrefix(field.Name, prefix); trimmed == field.Name || len(trimmed) == 0 || unicode.IsDigit(rune(trimmed[0])) {
+			return ""
+		}
+	}
+	return prefix
+}
+
 // parseProtoMessage .
 func (s *Swagger) parseProtoMessage(mess *protoc.Message) {
 	var def = &Definition{
@@ -150,27 +345,29 @@ func (s *Swagger) parseProtoMessage(mess *protoc.Message) {
 		Type:        "object",
 		Description: mess.Description,
 	}
-	fields := make(map[string]*Definition, 0)
 
-	for _, mf := range mess.Fields {
-		fields[mf.ProtoName] = s.parseProtoMessageField(mf)
-	}
+	// 先于字段解析保存, 自引用或相互引用的 message 以 $ref 引用
+	s.Definitions[mess.Name] = def
 
-	def.Nesteds = fields
+	for _, mf := range mess.Fields {
+		def.property(mf.ProtoName, s.parseProtoMessageField(mf))
 
-	s.Definitions[mess.Name] = def
+		if mf.Required {
+			def.Required = append(def.Required, mf.ProtoName)
+		}
+	}
 }
 
 // parseProtoMessageField .
 func (s *Swagger) parseProtoMessageField(mf *protoc.MessageField) *Definition {
 	var field = new(Definition)
-	if def, found := prototypes[mf.ProtoType]; found {
+	if def, found := scalar(mf.ProtoType); found {
 		field = def
 	} else {
 		switch mf.ProtoType {
 		case descriptorpb.FieldDescriptorProto_TYPE_ENUM:
 			field.Reflex = s.reflex(mf.ProtoTypeName).Reflex
-		case descriptorpb.FieldDescriptorProto_TYPE_MESSAGE:
+		case descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, descriptorpb.FieldDescriptorProto_TYPE_GROUP:
 			// 优先解析嵌套 message
 			if _, found := s.Definitions[mf.ProtoTypeName]; !found {
 				if mess, found := s.p.MessageDic[mf.ProtoTypeName]; found {
@@ -179,11 +376,10 @@ func (s *Swagger) parseProtoMessageField(mf *protoc.MessageField) *Definition {
 			}
 
 			if protoc.IsEntry(mf) {
-				if entry, found := s.Definitions[mf.ProtoTypeName]; found && len(entry.Nesteds) != 0 {
-					// if key, k_found := entry.Nesteds["key"]; k_found {
-					//
-					// }
-					if val, v_found := entry.Nesteds["value"]; v_found {
+				// map<key, value>
+				field.Type = "object"
+				if entry, found := s.Definitions[mf.ProtoTypeName]; found && entry.Nesteds.Len() != 0 {
+					if val, v_found := entry.Nesteds.Get("value"); v_found {
 						field.Entry = val
 					}
 				}
@@ -194,32 +390,78 @@ func (s *Swagger) parseProtoMessageField(mf *protoc.MessageField) *Definition {
 	}
 
 	// proto laber
-	switch mf.ProtoLaber {
+	switch {
+	// map
+	case protoc.IsEntry(mf):
 	// repeated
-	case descriptorpb.FieldDescriptorProto_LABEL_REPEATED:
-		return &Definition{
+	case mf.ProtoLaber == descriptorpb.FieldDescriptorProto_LABEL_REPEATED:
+		field = &Definition{
 			Type:  "array",
 			Items: field,
 		}
-	default:
-		return field
 	}
+
+	field.ReadOnly = mf.OutputOnly
+	field.WriteOnly = mf.InputOnly
+	return field
 }
 
-// push api
-func (s *Swagger) push(uri string, method string, api *API) {
-	if apis, found := s.Paths[uri]; found {
-		if _, found := apis[method]; found {
-			logger.Fatalf("duplicate route. %s [%s]", uri, method)
+// parameter 根据 message 字段生成参数
+func (s *Swagger) parameter(in Position, name string, mf *protoc.MessageField) *Parameter {
+	var parameter = &Parameter{
+		In:          in,
+		Name:        name,
+		Type:        "string",
+		Required:    mf.Required,
+		Description: mf.Description,
+	}
+
+	if def, found := scalar(mf.ProtoType); found {
+		parameter.Type = def.Type
+		parameter.Format = def.Format
+	} else if mf.ProtoType == descriptorpb.FieldDescriptorProto_TYPE_ENUM {
+		if def, found := s.Definitions[mf.ProtoTypeName]; found {
+			parameter.Type = def.Type
+			parameter.Format = def.Format
+			parameter.Enum = def.Enum
+			parameter.Default = def.Default
+
+			// 名称与数值均可时以 string 表示
+			if len(parameter.Type) == 0 {
+				parameter.Type = "string"
+				parameter.Enum = make([]interface{}, 0, len(def.Enum))
+				for _, value := range def.Enum {
+					parameter.Enum = append(parameter.Enum, fmt.Sprint(value))
+				}
+				parameter.Default = fmt.Sprint(def.Default)
+			}
 		}
+	}
 
-		apis[method] = api
-	} else {
-		var apis = make(map[string]*API, 0)
-		apis[method] = api
+	// repeated
+	if mf.ProtoLaber == descriptorpb.FieldDescriptorProto_LABEL_REPEATED {
+		parameter.Items = &Definition{
+			Type:    parameter.Type,
+			Format:  parameter.Format,
+			Enum:    parameter.Enum,
+			Default: parameter.Default,
+		}
+		parameter.Type = "array"
+		parameter.Format = ""
+		parameter.Enum = nil
+		parameter.Default = nil
+		parameter.CollectionFormat = "multi"
+	}
+	return parameter
+}
 
-		s.Paths[uri] = apis
+// push api
+func (s *Swagger) push(uri string, method string, api *API) {
+	if exist, found := s.Paths.Get(uri, method); found {
+		diagnostic.Errorf(api.rpc.Position, "duplicate route %s [%s], already declared by %s at %s", uri, strings.ToUpper(method), exist.rpc.Name, exist.rpc.Position)
+		return
 	}
+	s.Paths.set(uri, method, api)
 }
 
 type Position string
@@ -246,183 +488,273 @@ func (api *API) parameterPosition(m *protoc.ServiceMethod) Position {
 	}
 }
 
-// parseResponses .
-func (api *API) parseResponses(s *Swagger, m *protoc.ServiceMethod) {
-	api.Responses = map[string]*Parameter{
-		"200": {
-			Description: "successful",
-			Schema:      s.reflex(m.ResponseName),
-		},
-	}
-}
-
 // parseParameter .
-func (api *API) parseParameter(s *Swagger, m *protoc.ServiceMethod) {
-	api.parseParameterInHeader()
-	api.parseParameterInPath(m)
+func (api *API) parseParameter(s *Swagger, srv *protoc.Service, m *protoc.ServiceMethod, uri string, params []*protoc.PathParam) {
+	api.parseParameterInHeader(srv, m, uri)
+	api.parseParameterInPath(s, m, params)
+
+	// path 中已声明的参数
+	var inpath = make(map[string]bool, len(params))
+	for _, param := range params {
+		inpath[param.Name] = true
+	}
 
 	switch api.parameterPosition(m) {
 	case PositionBody:
-		api.parseParameterInBody(s, m)
+		api.parseParameterInBody(s, m, params)
 	case PositionQuery:
-		api.parseParameterInQuery(s, m)
+		api.parseParameterInQuery(s, m, inpath)
 	case PositionFormData:
-		api.parseParamterInFormData(s, m)
+		api.parseParamterInFormData(s, m, inpath)
 	}
 }
 
-// parseParameterInHeader .
-func (api *API) parseParameterInHeader() {
-	// Header
-	for name, desc := range conf.Get().Header {
-		api.Parameters = append(api.Parameters, &Parameter{
+// parseParameterInHeader . include 与 exclude 匹配 service、service.rpc 与文档中的 uri
+func (api *API) parseParameterInHeader(srv *protoc.Service, m *protoc.ServiceMethod, uri string) {
+	for _, header := range headers() {
+		if !protoc.Matches(header.Include, header.Exclude, srv.Name, srv.Name+"."+m.Name, uri) {
+			continue
+		}
+
+		var parameter = &Parameter{
 			In:          PositionHeader,
-			Name:        name,
-			Type:        "string",
-			Required:    false,
-			Description: desc,
-		})
+			Name:        header.Name,
+			Type:        header.Type,
+			Format:      header.Format,
+			Required:    header.Required,
+			Description: header.Description,
+		}
+		if len(parameter.Type) == 0 {
+			parameter.Type = "string"
+		}
+		for _, value := range header.Enum {
+			parameter.Enum = append(parameter.Enum, headerValue(parameter.Type, value))
+		}
+		if len(header.Default) != 0 {
+			parameter.Default = headerValue(parameter.Type, header.Default)
+		}
+		if len(header.Example) != 0 {
+			parameter.Example = headerValue(parameter.Type, header.Example)
+		}
+		api.Parameters = append(api.Parameters, parameter)
 	}
 }
 
-// parseParameter .
-func (api *API) parseParameterInPath(m *protoc.ServiceMethod) {
-	var uri = m.Path
-	for len(uri) > 2 {
-		l, r := strings.Index(uri, "{"), strings.Index(uri, "}")
-		if l > 0 && r > 0 && r > l {
-			api.Parameters = append(api.Parameters, &Parameter{
-				In:       PositionPath,
-				Name:     uri[l+1 : r],
-				Type:     "string",
-				Required: false,
-			})
-			uri = uri[r+1:]
-		} else {
-			return
+// headerValue 按请求头类型转换 enum、default 与 example. 转换失败时保留字符串
+func headerValue(typ string, value string) interface{} {
+	if converted, err := convertHeaderValue(typ, value); err == nil {
+		return converted
+	}
+	return value
+}
+
+// convertHeaderValue .
+func convertHeaderValue(typ string, value string) (interface{}, error) {
+	switch typ {
+	case "integer":
+		return strconv.ParseInt(value, 10, 64)
+	case "number":
+		return strconv.ParseFloat(value, 64)
+	case "boolean":
+		return strconv.ParseBool(value)
+	default:
+		return value, nil
+	}
+}
+
+// validateHeaders 检查请求头 enum、default 与 example 是否与类型一致
+func validateHeaders() {
+	for _, header := range headers() {
+		var typ = header.Type
+		if len(typ) == 0 {
+			typ = "string"
+		}
+
+		var values = append(append([]string{}, header.Enum...), header.Default, header.Example)
+		for _, value := range values {
+			if len(value) == 0 {
+				continue
+			}
+			if _, err := convertHeaderValue(typ, value); err != nil {
+				diagnostic.Warnf(conf.Position(), "invalid %s value %q of header %s", typ, value, header.Name)
+			}
+		}
+	}
+}
+
+// headers 请求头列表. [header] 按名称排序追加在 [[headers]] 之后
+func headers() []*conf.Header {
+	var list = make([]*conf.Header, 0, len(conf.Get().Headers)+len(conf.Get().Header))
+	var names = make(map[string]bool, 0)
+	for _, header := range conf.Get().Headers {
+		names[header.Name] = true
+		list = append(list, header)
+	}
+
+	var legacy = make([]string, 0, len(conf.Get().Header))
+	for name := range conf.Get().Header {
+		if !names[name] {
+			legacy = append(legacy, name)
 		}
 	}
+	sort.Strings(legacy)
+
+	for _, name := range legacy {
+		list = append(list, &conf.Header{Name: name, Description: conf.Get().Header[name]})
+	}
+	return list
 }
 
 // parseParameterInBody .
-func (api *API) parseParameterInBody(s *Swagger, m *protoc.ServiceMethod) {
+func (api *API) parseParameterInBody(s *Swagger, m *protoc.ServiceMethod, params []*protoc.PathParam) {
+	var schema = s.reflex(m.RequestName)
+
+	// 移除 path 中已声明的参数与 OUTPUT_ONLY 字段
+	var fields = make([][]string, 0, len(params))
+	for _, param := range params {
+		fields = append(fields, param.Fields())
+	}
+	if mess, found := s.p.MessageDic[m.RequestName]; found {
+		for _, mf := range mess.Fields {
+			if mf.OutputOnly {
+				fields = append(fields, []string{mf.ProtoName})
+			}
+		}
+	}
+
+	if len(fields) != 0 {
+		if def, found := s.Definitions[m.RequestName]; found {
+			schema = s.exclude(def, fields)
+			if schema.Nesteds.Len() == 0 {
+				return
+			}
+		}
+	}
+
 	api.Parameters = append(api.Parameters, &Parameter{
 		In:          PositionBody,
 		Name:        m.Name,
 		Required:    false,
 		Description: m.Description,
-		Schema:      s.reflex(m.RequestName),
+		Schema:      schema,
 	})
 }
 
-// parseParameter .
-func (api *API) parseParameterInQuery(s *Swagger, m *protoc.ServiceMethod) {
-	if mess, found := s.Definitions[m.RequestName]; found {
-		// message fields
-		for name, field := range mess.Nesteds {
-			switch field.Type {
-			case "array":
-				// repeated nesteds
-				if len(field.Items.Reflex) != 0 {
-					// query 中的 nesteds 只允许为 enum
-					if def, found := s.Definitions[strings.TrimPrefix(field.Items.Reflex, refprefix)]; found && len(def.Enum) != 0 {
-						api.Parameters = append(api.Parameters, &Parameter{
-							In:          PositionQuery,
-							Name:        name,
-							Type:        field.Type,
-							Required:    false,
-							Description: field.Description,
-							Items: &Definition{
-								Type:    def.Type,
-								Enum:    def.Enum,
-								Default: def.Default,
-							},
-						})
-					}
-				} else {
-					api.Parameters = append(api.Parameters, &Parameter{
-						In:          PositionQuery,
-						Name:        name,
-						Type:        field.Type,
-						Required:    false,
-						Description: field.Description,
-						Items: &Definition{
-							Type: field.Items.Type,
-						},
-					})
-				}
-			default:
-				// nesteds
-				if len(field.Reflex) != 0 {
-					// query 中的 nesteds 只允许为 enum
-					if def, found := s.Definitions[strings.TrimPrefix(field.Reflex, refprefix)]; found && len(def.Enum) != 0 {
-						api.Parameters = append(api.Parameters, &Parameter{
-							In:          PositionQuery,
-							Name:        name,
-							Type:        def.Type,
-							Required:    false,
-							Enum:        def.Enum,
-							Default:     def.Default,
-							Description: def.Description,
-						})
-					}
-				} else {
-					api.Parameters = append(api.Parameters, &Parameter{
-						In:          PositionQuery,
-						Name:        name,
-						Type:        field.Type,
-						Required:    false,
-						Description: field.Description,
-					})
-				}
+// parseParameterInQuery .
+func (api *API) parseParameterInQuery(s *Swagger, m *protoc.ServiceMethod, inpath map[string]bool) {
+	var offset = len(api.Parameters)
+	api.parseParameterInQueryMessage(s, m.RequestName, "", inpath, map[string]bool{m.RequestName: true}, QueryDepth())
+
+	// 默认按字段声明顺序
+	if alphabetical() {
+		var query = api.Parameters[offset:]
+		sort.SliceStable(query, func(i, j int) bool {
+			return query[i].Name < query[j].Name
+		})
+	}
+}
+
+// parseParameterInQueryMessage 展开 message 字段为 query 参数. 嵌套 message 以 "." 连接. e.g. filter.status=1&page.size=20
+func (api *API) parseParameterInQueryMessage(s *Swagger, message string, prefix string, inpath map[string]bool, visited map[string]bool, depth int) {
+	mess, found := s.p.MessageDic[message]
+	if !found {
+		return
+	}
+
+	for _, mf := range mess.Fields {
+		var name = prefix + mf.ProtoName
+		if inpath[name] || mf.OutputOnly {
+			continue
+		}
+
+		switch {
+		case protoc.IsEntry(mf):
+			s.dropped(mf, "map field is not supported in query, ignored. %s.%s", message, mf.ProtoName)
+		case mf.ProtoType == descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, mf.ProtoType == descriptorpb.FieldDescriptorProto_TYPE_GROUP:
+			if mf.ProtoLaber == descriptorpb.FieldDescriptorProto_LABEL_REPEATED {
+				s.dropped(mf, "repeated message is not supported in query, ignored. %s.%s", message, mf.ProtoName)
+				continue
+			}
+
+			// 嵌套层数限制与循环引用
+			if visited[mf.ProtoTypeName] {
+				s.dropped(mf, "recursive message is not supported in query, ignored. %s.%s", message, mf.ProtoName)
+				continue
 			}
+			if depth <= 1 {
+				s.dropped(mf, "nested message exceeds query depth %d, ignored. %s.%s", QueryDepth(), message, mf.ProtoName)
+				continue
+			}
+
+			visited[mf.ProtoTypeName] = true
+			api.parseParameterInQueryMessage(s, mf.ProtoTypeName, name+".", inpath, visited, depth-1)
+			delete(visited, mf.ProtoTypeName)
+		default:
+			api.Parameters = append(api.Parameters, s.parameter(PositionQuery, name, mf))
 		}
 	}
 }
 
+// dropped query 中被忽略的字段. 每个字段仅警告一次. 启用 lint 时由 query_field 规则输出
+func (s *Swagger) dropped(mf *protoc.MessageField, format string, v ...interface{}) {
+	if conf.Get().Lint.Enabled {
+		return
+	}
+
+	var key = mf.Position.String() + " " + mf.MessageName + "." + mf.ProtoName
+	if s.drops[key] {
+		return
+	}
+	s.drops[key] = true
+
+	diagnostic.Warnf(mf.Position, format, v...)
+}
+
 // parseParamterInFormData .
-func (api *API) parseParamterInFormData(s *Swagger, m *protoc.ServiceMethod) {
-	if mess, found := s.Definitions[m.RequestName]; found {
-		// message fields
-		for name, field := range mess.Nesteds {
-			switch field.Type {
-			case "array":
-				// multipart/form-data 参数不支持 array
-			default:
-				// nesteds
-				if len(field.Reflex) != 0 {
-					// multipart/form-data 中的 nesteds 只允许为 enum
-					if def, found := s.Definitions[strings.TrimPrefix(field.Reflex, refprefix)]; found && len(def.Enum) != 0 {
-						api.Parameters = append(api.Parameters, &Parameter{
-							In:          PositionFormData,
-							Name:        name,
-							Type:        def.Type,
-							Required:    false,
-							Enum:        def.Enum,
-							Default:     def.Default,
-							Description: def.Description,
-						})
-					}
-				} else {
-					if field.Format == "bytes" {
-						api.Parameters = append(api.Parameters, &Parameter{
-							In:          PositionFormData,
-							Name:        name,
-							Type:        "file",
-							Required:    false,
-							Description: field.Description,
-						})
-					} else {
-						api.Parameters = append(api.Parameters, &Parameter{
-							In:          PositionFormData,
-							Name:        name,
-							Type:        field.Type,
-							Required:    false,
-							Description: field.Description,
-						})
-					}
-				}
+func (api *API) parseParamterInFormData(s *Swagger, m *protoc.ServiceMethod, inpath map[string]bool) {
+	mess, found := s.p.MessageDic[m.RequestName]
+	if !found {
+		return
+	}
+
+	// message fields
+	for _, mf := range mess.Fields {
+		if inpath[mf.ProtoName] || mf.OutputOnly {
+			continue
+		}
+
+		var parameter = s.parameter(PositionFormData, mf.ProtoName, mf)
+		switch mf.ProtoType {
+		case descriptorpb.FieldDescriptorProto_TYPE_BYTES:
+			// file. swagger 2.0 不支持 file 数组, repeated bytes 为可重复的同名文件字段
+			if parameter.Items != nil {
+				parameter.Description = strings.TrimSpace(parameter.Description + " (repeatable)")
 			}
+			parameter.Type = "file"
+			parameter.Format = ""
+			parameter.Items = nil
+			parameter.CollectionFormat = ""
+		case descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, descriptorpb.FieldDescriptorProto_TYPE_GROUP:
+			// nesteds 以 JSON 字符串传递
+			parameter.Type = "string"
+			parameter.Format = "json"
+			parameter.Items = nil
+			parameter.CollectionFormat = ""
+			parameter.Description = strings.TrimSpace(parameter.Description + " (JSON: " + formDataJSONType(mf) + ")")
 		}
+
+		api.Parameters = append(api.Parameters, parameter)
+	}
+}
+
+// formDataJSONType multipart/form-data 中 JSON 字符串参数的结构说明
+func formDataJSONType(mf *protoc.MessageField) string {
+	switch {
+	case protoc.IsEntry(mf):
+		return "map"
+	case mf.ProtoLaber == descriptorpb.FieldDescriptorProto_LABEL_REPEATED:
+		return "[" + mf.ProtoTypeName + "]"
+	default:
+		return mf.ProtoTypeName
 	}
 }
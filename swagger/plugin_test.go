@@ -0,0 +1,140 @@
+package swagger
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/charlesbases/protoc-gen-swagger/protoc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// field message 字段
+func field(name string, number int32, label descriptorpb.FieldDescriptorProto_Label, typename string) *descriptorpb.FieldDescriptorProto {
+	return &descriptorpb.FieldDescriptorProto{
+		Name:     proto.String(name),
+		Number:   proto.Int32(number),
+		Label:    label.Enum(),
+		Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+		TypeName: proto.String(typename),
+	}
+}
+
+// generate 以 request 为 rpc 请求与响应生成文档. 超时视为未终止
+func generate(t *testing.T, request string, messages ...*descriptorpb.DescriptorProto) map[string]interface{} {
+	var req = &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{"pb/recursion.proto"},
+		ProtoFile: []*descriptorpb.FileDescriptorProto{{
+			Name:        proto.String("pb/recursion.proto"),
+			Package:     proto.String("pb"),
+			Syntax:      proto.String("proto3"),
+			MessageType: messages,
+			Service: []*descriptorpb.ServiceDescriptorProto{{
+				Name: proto.String("Recursion"),
+				Method: []*descriptorpb.MethodDescriptorProto{{
+					Name:       proto.String("Get"),
+					InputType:  proto.String(".pb." + request),
+					OutputType: proto.String(".pb." + request),
+				}},
+			}},
+		}},
+	}
+
+	var done = make(chan *pluginpb.CodeGeneratorResponse, 1)
+	go func() {
+		done <- protoc.Generate(req, func(p *protoc.Package) *pluginpb.CodeGeneratorResponse {
+			return &pluginpb.CodeGeneratorResponse{File: []*pluginpb.CodeGeneratorResponse_File{New(p).Generater()}}
+		})
+	}()
+
+	select {
+	case rsp := <-done:
+		if len(rsp.GetError()) != 0 {
+			t.Fatal(rsp.GetError())
+		}
+
+		var spec = make(map[string]interface{}, 0)
+		if err := json.Unmarshal([]byte(rsp.GetFile()[0].GetContent()), &spec); err != nil {
+			t.Fatal(err)
+		}
+		return spec
+	case <-time.After(5 * time.Second):
+		t.Fatal("generation did not terminate")
+		return nil
+	}
+}
+
+// lookup 获取文档中的值. e.g. lookup(spec, "definitions", "Node", "properties")
+func lookup(value interface{}, keys ...string) interface{} {
+	for _, key := range keys {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[key]
+	}
+	return value
+}
+
+// expect 字段引用的 Definition
+func expect(t *testing.T, spec map[string]interface{}, ref string, keys ...string) {
+	if value := lookup(spec, append(keys, "$ref")...); value != ref {
+		t.Errorf("%v: expected $ref %s, got %v", keys, ref, value)
+	}
+}
+
+// TestDirectRecursion message Node { Node child = 1; repeated Node children = 2; }
+func TestDirectRecursion(t *testing.T) {
+	var spec = generate(t, "Node", &descriptorpb.DescriptorProto{
+		Name: proto.String("Node"),
+		Field: []*descriptorpb.FieldDescriptorProto{
+			field("child", 1, descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL, ".pb.Node"),
+			field("children", 2, descriptorpb.FieldDescriptorProto_LABEL_REPEATED, ".pb.Node"),
+		},
+	})
+
+	expect(t, spec, "#/definitions/Node", "definitions", "Node", "properties", "child")
+	expect(t, spec, "#/definitions/Node", "definitions", "Node", "properties", "children", "items")
+}
+
+// TestMutualRecursion message A { B b = 1; } message B { A a = 1; }
+func TestMutualRecursion(t *testing.T) {
+	var spec = generate(t, "A",
+		&descriptorpb.DescriptorProto{
+			Name:  proto.String("A"),
+			Field: []*descriptorpb.FieldDescriptorProto{field("b", 1, descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL, ".pb.B")},
+		},
+		&descriptorpb.DescriptorProto{
+			Name:  proto.String("B"),
+			Field: []*descriptorpb.FieldDescriptorProto{field("a", 1, descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL, ".pb.A")},
+		},
+	)
+
+	expect(t, spec, "#/definitions/B", "definitions", "A", "properties", "b")
+	expect(t, spec, "#/definitions/A", "definitions", "B", "properties", "a")
+}
+
+// TestMapRecursion message Node { map<string, Node> children = 1; }
+func TestMapRecursion(t *testing.T) {
+	var spec = generate(t, "Node", &descriptorpb.DescriptorProto{
+		Name:  proto.String("Node"),
+		Field: []*descriptorpb.FieldDescriptorProto{field("children", 1, descriptorpb.FieldDescriptorProto_LABEL_REPEATED, ".pb.Node.ChildrenEntry")},
+		NestedType: []*descriptorpb.DescriptorProto{{
+			Name:    proto.String("ChildrenEntry"),
+			Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+			Field: []*descriptorpb.FieldDescriptorProto{
+				{
+					Name:   proto.String("key"),
+					Number: proto.Int32(1),
+					Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+					Type:   descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+				},
+				field("value", 2, descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL, ".pb.Node"),
+			},
+		}},
+	})
+
+	expect(t, spec, "#/definitions/Node", "definitions", "Node", "properties", "children", "additionalProperties")
+}
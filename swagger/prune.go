@@ -0,0 +1,79 @@
+package swagger
+
+import (
+	"strings"
+)
+
+// prune 移除未被 api 引用的 Definition. candidates 为空时检查全部 Definition
+func (s *Swagger) prune(candidates map[string]bool) {
+	if candidates != nil {
+		// candidates 所引用的 Definition
+		for name := range candidates {
+			if def, found := s.Definitions[name]; found {
+				s.references(def, candidates)
+			}
+		}
+	}
+
+	var reachable = s.reachable()
+	if candidates != nil {
+		// 保留的 Definition 所引用的 Definition 同样保留
+		for name, def := range s.Definitions {
+			if !candidates[name] {
+				s.references(def, reachable)
+			}
+		}
+	}
+	for name := range s.Definitions {
+		if reachable[name] {
+			continue
+		}
+		if candidates == nil || candidates[name] {
+			delete(s.Definitions, name)
+		}
+	}
+}
+
+// reachable api 引用的 Definition
+func (s *Swagger) reachable() map[string]bool {
+	var refs = make(map[string]bool, 0)
+	s.Paths.Range(func(uri string, method string, api *API) {
+		for _, parameter := range api.Parameters {
+			if parameter.Schema != nil {
+				s.references(parameter.Schema, refs)
+			}
+			if parameter.Items != nil {
+				s.references(parameter.Items, refs)
+			}
+		}
+		for _, rsp := range api.Responses {
+			if rsp.Schema != nil {
+				s.references(rsp.Schema, refs)
+			}
+		}
+	})
+	return refs
+}
+
+// references 递归查找 Definition 引用的 Definition. $ref, items, additionalProperties, properties
+func (s *Swagger) references(def *Definition, refs map[string]bool) {
+	if len(def.Reflex) != 0 {
+		var name = strings.TrimPrefix(def.Reflex, refprefix)
+		if !refs[name] {
+			refs[name] = true
+			if ref, found := s.Definitions[name]; found {
+				s.references(ref, refs)
+			}
+		}
+	}
+	if def.Items != nil {
+		s.references(def.Items, refs)
+	}
+	if def.Entry != nil {
+		s.references(def.Entry, refs)
+	}
+	for _, name := range def.Nesteds.Keys() {
+		nested, _ := def.Nesteds.Get(name)
+		s.references(nested, refs)
+	}
+}
@@ -0,0 +1,329 @@
+package swagger
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/charlesbases/protoc-gen-swagger/conf"
+	"github.com/charlesbases/protoc-gen-swagger/diagnostic"
+	"github.com/charlesbases/protoc-gen-swagger/protoc"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// DefaultErrorName 自定义错误响应结构默认名称
+const DefaultErrorName = "Error"
+
+// RPCStatus google.rpc.Status
+const RPCStatus = "google.rpc.Status"
+
+// DefaultEnvelopeSuffix 响应包装结构名后缀
+const DefaultEnvelopeSuffix = "Envelope"
+
+// parseResponses .
+func (api *API) parseResponses(s *Swagger, srv *protoc.Service, m *protoc.ServiceMethod) {
+	// @payload 仅用于文件下载
+	if directives := m.Directives.Filter("payload"); len(directives) != 0 && !isBinary(m.Produce) {
+		diagnostic.Warnf(directives[0].Position, "@payload ignored, %s [%s] produces %s, not a file", m.Path, m.Method, m.Produce)
+	}
+
+	switch {
+	case isBinary(m.Produce):
+		api.Responses["200"] = s.parseBinaryResponse(m)
+	case enveloped(srv, m):
+		api.Responses["200"] = &Response{
+			Description: "successful",
+			Schema:      s.reflex(s.parseEnvelope(m.ResponseName)),
+		}
+	default:
+		api.Responses["200"] = &Response{
+			Description: "successful",
+			Schema:      s.responseSchema(m.ResponseName),
+		}
+	}
+
+	// error
+	if name := s.errorName; len(name) != 0 {
+		for _, code := range errorCodes() {
+			api.Responses[code] = &Response{
+				Description: statusText(code),
+				Schema:      s.reflex(name),
+			}
+		}
+	}
+
+	api.parseResponseDirectives(s, m)
+}
+
+// enveloped 是否包装响应. service 或 rpc 注释中可通过 @envelope off 关闭
+func enveloped(srv *protoc.Service, m *protoc.ServiceMethod) bool {
+	if len(conf.Get().Envelope.Data) == 0 {
+		return false
+	}
+	for _, ds := range []protoc.Directives{m.Directives, srv.Directives} {
+		if value, found := ds.Lookup("envelope"); found {
+			return value != "off" && value != "false"
+		}
+	}
+	return true
+}
+
+// parseEnvelope 生成响应包装结构, 返回结构名. e.g. ResponseEnvelope. 与 proto message 重名时追加序号. e.g. ResponseEnvelope_2
+func (s *Swagger) parseEnvelope(response string) string {
+	if name, found := s.envelopes[response]; found {
+		return name
+	}
+
+	var name = s.definitionName(response+DefaultEnvelopeSuffix, "envelope")
+	var def = s.schema(name, conf.Get().Envelope.Fields)
+	def.property(conf.Get().Envelope.Data, s.responseSchema(response))
+
+	s.Definitions[name] = def
+	s.envelopes[response] = name
+	return name
+}
+
+// responseSchema 响应结构. 移除 INPUT_ONLY 字段
+func (s *Swagger) responseSchema(response string) *Definition {
+	var schema = s.reflex(response)
+
+	var fields = make([][]string, 0)
+	if mess, found := s.p.MessageDic[response]; found {
+		for _, mf := range mess.Fields {
+			if mf.InputOnly {
+				fields = append(fields, []string{mf.ProtoName})
+			}
+		}
+	}
+
+	if len(fields) != 0 {
+		if def, found := s.Definitions[response]; found {
+			schema = s.exclude(def, fields)
+		}
+	}
+	return schema
+}
+
+// parseResponseDirectives 解析 rpc 注释中的响应. e.g. @response 404 NotFound 用户不存在
+func (api *API) parseResponseDirectives(s *Swagger, m *protoc.ServiceMethod) {
+	for _, directive := range m.Directives.Filter("response") {
+		var fields = strings.Fields(directive.Value)
+		if len(fields) == 0 || !isStatusCode(fields[0]) {
+			diagnostic.Warnf(directive.Position, "invalid directive: @response %s. %s [%s]", directive.Value, m.Path, m.Method)
+			continue
+		}
+
+		var rsp = &Response{Description: statusText(fields[0])}
+		if name := s.errorName; len(name) != 0 {
+			rsp.Schema = s.reflex(name)
+		}
+
+		// schema
+		if len(fields) > 1 {
+			if _, found := s.Definitions[fields[1]]; found {
+				rsp.Schema = s.reflex(fields[1])
+				fields = append(fields[:1], fields[2:]...)
+			}
+		}
+		// description
+		if len(fields) > 1 {
+			rsp.Description = strings.Join(fields[1:], " ")
+		}
+
+		api.Responses[fields[0]] = rsp
+	}
+}
+
+// isStatusCode default 或 http status code
+func isStatusCode(code string) bool {
+	if code == "default" {
+		return true
+	}
+	status, err := strconv.Atoi(code)
+	return err == nil && status >= 100 && status <= 599
+}
+
+// statusText .
+func statusText(code string) string {
+	if status, err := strconv.Atoi(code); err == nil {
+		if text := http.StatusText(status); len(text) != 0 {
+			return text
+		}
+	}
+	return "An unexpected error response."
+}
+
+// errorCodes 错误响应码. 未配置时为 default
+func errorCodes() []string {
+	if codes := conf.Get().Error.Codes; len(codes) != 0 {
+		return codes
+	}
+	return []string{"default"}
+}
+
+// errorName 错误响应结构名. 未配置错误响应时返回空
+func errorName() string {
+	var model = conf.Get().Error
+	switch {
+	case model.Message == RPCStatus:
+		return "rpcStatus"
+	case len(model.Message) != 0:
+		return model.Message
+	case len(model.Fields) != 0:
+		if len(model.Name) != 0 {
+			return model.Name
+		}
+		return DefaultErrorName
+	default:
+		return ""
+	}
+}
+
+// parseErrorDefinition 错误响应结构
+func (s *Swagger) parseErrorDefinition() {
+	var model = conf.Get().Error
+	switch {
+	case model.Message == RPCStatus:
+		var protobufAny = &Definition{Name: "protobufAny", Type: "object", Entry: new(Definition)}
+		protobufAny.property("@type", &Definition{Type: "string"})
+		s.Definitions[protobufAny.Name] = protobufAny
+
+		var rpcStatus = &Definition{Name: "rpcStatus", Type: "object"}
+		rpcStatus.property("code", &Definition{Type: "integer", Format: "int32"})
+		rpcStatus.property("message", &Definition{Type: "string"})
+		rpcStatus.property("details", &Definition{Type: "array", Items: s.reflex(protobufAny.Name)})
+		s.Definitions[rpcStatus.Name] = rpcStatus
+	case len(model.Message) != 0:
+		if _, found := s.Definitions[model.Message]; !found {
+			diagnostic.Warnf(conf.Position(), "error message %s not found, error responses are not generated", model.Message)
+			return
+		}
+	case len(model.Fields) != 0:
+		var name = s.definitionName(errorName(), "error")
+		s.Definitions[name] = s.schema(name, model.Fields)
+		s.errorName = name
+		return
+	}
+	s.errorName = errorName()
+}
+
+// definitionName 自定义结构名. 与 proto message 或 enum 重名时输出警告并追加序号. e.g. Error_2
+func (s *Swagger) definitionName(name string, kind string) string {
+	if _, found := s.Definitions[name]; !found {
+		return name
+	}
+
+	var unique = name
+	for idx := 2; s.Definitions[unique] != nil; idx++ {
+		unique = name + "_" + strconv.Itoa(idx)
+	}
+
+	var position = conf.Position()
+	if mess, found := s.p.MessageDic[name]; found {
+		position = mess.Position
+	} else if enum, found := s.p.EnumDic[name]; found {
+		position = enum.Position
+	}
+	diagnostic.Warnf(position, "%s definition %s conflicts with proto definition %s, renamed to %s", kind, name, name, unique)
+	return unique
+}
+
+// schema 根据自定义字段生成结构
+func (s *Swagger) schema(name string, fields []*conf.Field) *Definition {
+	var def = &Definition{
+		Name: name,
+		Type: "object",
+	}
+
+	for _, field := range fields {
+		if len(field.Ref) != 0 {
+			def.property(field.Name, s.reflex(field.Ref))
+			continue
+		}
+
+		var nested = &Definition{
+			Type:        field.Type,
+			Format:      field.Format,
+			Description: field.Description,
+		}
+		if len(nested.Type) == 0 {
+			nested.Type = "string"
+		}
+		def.property(field.Name, nested)
+	}
+	return def
+}
+
+// isBinary 是否为文件下载
+func isBinary(contentType string) bool {
+	switch {
+	case contentType == "application/octet-stream", contentType == "multipart/form-data":
+		return true
+	case strings.HasPrefix(contentType, "image/"), strings.HasPrefix(contentType, "audio/"), strings.HasPrefix(contentType, "video/"):
+		return true
+	default:
+		return false
+	}
+}
+
+// parseBinaryResponse 文件下载. 响应内容为 response message 中的 bytes 字段, 可通过 @payload 指定
+func (s *Swagger) parseBinaryResponse(m *protoc.ServiceMethod) *Response {
+	var rsp = &Response{
+		Description: "successful",
+		Schema:      &Definition{Type: "file"},
+		Headers: map[string]*Header{
+			"Content-Type": {
+				Type:        "string",
+				Description: m.Produce,
+			},
+			"Content-Disposition": {
+				Type:        "string",
+				Description: `attachment; filename="<filename>"`,
+			},
+		},
+	}
+
+	if payload := s.payload(m); payload != nil {
+		rsp.Description = payload.Description
+	}
+	return rsp
+}
+
+// payload 文件下载响应中的 bytes 字段. @payload 指定的字段不存在或不是 bytes 时输出警告
+func (s *Swagger) payload(m *protoc.ServiceMethod) *protoc.MessageField {
+	mess, found := s.p.MessageDic[m.ResponseName]
+	if !found {
+		return nil
+	}
+
+	var isBytes = func(mf *protoc.MessageField) bool {
+		return mf.ProtoType == descriptorpb.FieldDescriptorProto_TYPE_BYTES && mf.ProtoLaber != descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+	}
+
+	// @payload
+	if directives := m.Directives.Filter("payload"); len(directives) != 0 {
+		var directive = directives[0]
+		for _, mf := range mess.Fields {
+			if mf.ProtoName != directive.Value {
+				continue
+			}
+			if !isBytes(mf) {
+				diagnostic.Warnf(directive.Position, "@payload %s is not a bytes field in %s. %s [%s]", directive.Value, m.ResponseName, m.Path, m.Method)
+				return nil
+			}
+			return mf
+		}
+
+		diagnostic.Warnf(directive.Position, "@payload %s not found in %s. %s [%s]", directive.Value, m.ResponseName, m.Path, m.Method)
+		return nil
+	}
+
+	for _, mf := range mess.Fields {
+		if isBytes(mf) {
+			return mf
+		}
+	}
+
+	diagnostic.Warnf(m.Position, "binary response %s has no bytes field. %s [%s]", m.ResponseName, m.Path, m.Method)
+	return nil
+}
@@ -0,0 +1,88 @@
+package swagger
+
+import (
+	"strings"
+
+	"github.com/charlesbases/protoc-gen-swagger/conf"
+	"github.com/charlesbases/protoc-gen-swagger/diagnostic"
+	"github.com/charlesbases/protoc-gen-swagger/protoc"
+)
+
+// SecurityNone 不需要认证. e.g. @security none
+const SecurityNone = "none"
+
+// parseSecurityDefinitions .
+func (s *Swagger) parseSecurityDefinitions() {
+	var cfg = conf.Get().Security
+	if len(cfg.Definitions) == 0 {
+		return
+	}
+
+	s.SecurityDefinitions = make(map[string]*SecurityScheme, len(cfg.Definitions))
+	for name, def := range cfg.Definitions {
+		var scheme = &SecurityScheme{
+			Type:        def.Type,
+			Description: def.Description,
+		}
+
+		switch def.Type {
+		case "apiKey":
+			scheme.Name = def.Name
+			scheme.In = def.In
+			if len(scheme.In) == 0 {
+				scheme.In = string(PositionHeader)
+			}
+		case "basic":
+		case "bearer":
+			// swagger 2.0 不支持 bearer, 以 Authorization header 表示
+			scheme.Type = "apiKey"
+			scheme.Name = "Authorization"
+			scheme.In = string(PositionHeader)
+			if len(scheme.Description) == 0 {
+				scheme.Description = "Bearer token. e.g. Bearer <token>"
+			}
+		case "oauth2":
+			scheme.Flow = def.Flow
+			scheme.AuthorizationURL = def.AuthorizationURL
+			scheme.TokenURL = def.TokenURL
+			scheme.Scopes = def.Scopes
+		default:
+			diagnostic.Warnf(conf.Position(), "unsupported security type %q in %s", def.Type, name)
+			continue
+		}
+
+		s.SecurityDefinitions[name] = scheme
+	}
+
+	s.Security = s.parseSecurityRequirements(cfg.Requirements, conf.Position())
+}
+
+// parseSecurityRequirements 解析认证. e.g. ["ApiKeyAuth"] ["OAuth2 read write"] ["none"]
+func (s *Swagger) parseSecurityRequirements(values []string, position protoc.Position) SecurityRequirements {
+	var requirements = make(SecurityRequirements, 0, len(values))
+	for _, value := range values {
+		var fields = strings.Fields(value)
+		if len(fields) == 0 || fields[0] == SecurityNone {
+			continue
+		}
+		if _, found := s.SecurityDefinitions[fields[0]]; !found {
+			diagnostic.Warnf(position, "security definition %s not found", fields[0])
+			continue
+		}
+
+		var scopes = fields[1:]
+		requirements = append(requirements, map[string][]string{fields[0]: scopes})
+	}
+	return requirements
+}
+
+// parseSecurity service 或 rpc 注释中可通过 @security 覆盖全局认证, rpc 注释优先. e.g. @security none
+func (api *API) parseSecurity(s *Swagger, srv *protoc.Service, m *protoc.ServiceMethod) {
+	for _, ds := range []protoc.Directives{m.Directives, srv.Directives} {
+		if directives := ds.Filter("security"); len(directives) != 0 {
+			var requirements = s.parseSecurityRequirements(ds.Values("security"), directives[0].Position)
+			api.Security = &requirements
+			return
+		}
+	}
+}
@@ -1,47 +1,88 @@
 package swagger
 
 import (
+	"github.com/charlesbases/protoc-gen-swagger/conf"
 	"github.com/charlesbases/protoc-gen-swagger/protoc"
 	"google.golang.org/protobuf/types/descriptorpb"
 )
 
+// prototypes proto 标量类型与 protojson 编码一致. e.g. bytes 为 base64 编码的字符串
 var prototypes = map[descriptorpb.FieldDescriptorProto_Type]*Definition{
-	descriptorpb.FieldDescriptorProto_TYPE_BYTES: {
-		Type:   "string",
-		Format: "bytes",
-	},
-	descriptorpb.FieldDescriptorProto_TYPE_STRING: {
-		Type:   "string",
-		Format: "string",
+	descriptorpb.FieldDescriptorProto_TYPE_DOUBLE: {
+		Type:   "number",
+		Format: "double",
 	},
 	descriptorpb.FieldDescriptorProto_TYPE_FLOAT: {
 		Type:   "number",
 		Format: "float",
 	},
-	descriptorpb.FieldDescriptorProto_TYPE_DOUBLE: {
-		Type:   "number",
-		Format: "double",
+	descriptorpb.FieldDescriptorProto_TYPE_INT32: {
+		Type:   "integer",
+		Format: "int32",
 	},
-	descriptorpb.FieldDescriptorProto_TYPE_BOOL: {
-		Type:   "boolean",
-		Format: "boolean",
+	descriptorpb.FieldDescriptorProto_TYPE_SINT32: {
+		Type:   "integer",
+		Format: "int32",
 	},
-	descriptorpb.FieldDescriptorProto_TYPE_INT32: {
+	descriptorpb.FieldDescriptorProto_TYPE_SFIXED32: {
 		Type:   "integer",
 		Format: "int32",
 	},
+	descriptorpb.FieldDescriptorProto_TYPE_UINT32: {
+		Type:   "integer",
+		Format: "uint32",
+	},
+	descriptorpb.FieldDescriptorProto_TYPE_FIXED32: {
+		Type:   "integer",
+		Format: "uint32",
+	},
 	descriptorpb.FieldDescriptorProto_TYPE_INT64: {
 		Type:   "integer",
 		Format: "int64",
 	},
-	descriptorpb.FieldDescriptorProto_TYPE_UINT32: {
+	descriptorpb.FieldDescriptorProto_TYPE_SINT64: {
 		Type:   "integer",
-		Format: "uint32",
+		Format: "int64",
+	},
+	descriptorpb.FieldDescriptorProto_TYPE_SFIXED64: {
+		Type:   "integer",
+		Format: "int64",
 	},
 	descriptorpb.FieldDescriptorProto_TYPE_UINT64: {
 		Type:   "integer",
 		Format: "uint64",
 	},
+	descriptorpb.FieldDescriptorProto_TYPE_FIXED64: {
+		Type:   "integer",
+		Format: "uint64",
+	},
+	descriptorpb.FieldDescriptorProto_TYPE_BOOL: {
+		Type: "boolean",
+	},
+	descriptorpb.FieldDescriptorProto_TYPE_STRING: {
+		Type: "string",
+	},
+	descriptorpb.FieldDescriptorProto_TYPE_BYTES: {
+		Type:   "string",
+		Format: "byte",
+	},
+}
+
+// Int64String 64 位整数以字符串表示, 与 protojson 一致
+const Int64String = "string"
+
+// scalar 标量类型. int64=string 时 64 位整数为 string
+func scalar(typ descriptorpb.FieldDescriptorProto_Type) (*Definition, bool) {
+	def, found := prototypes[typ]
+	if !found {
+		return nil, false
+	}
+
+	var copied = *def
+	if conf.Get().Int64 == Int64String && copied.Type == "integer" && (copied.Format == "int64" || copied.Format == "uint64") {
+		copied.Type = "string"
+	}
+	return &copied, true
 }
 
 // Swagger .
@@ -49,6 +90,15 @@ type Swagger struct {
 	name string          `json:"-"`
 	p    *protoc.Package `json:"-"`
 
+	// operations operationId list
+	operations map[string]bool `json:"-"`
+	// errorName 错误响应结构名. 未配置错误响应时为空
+	errorName string `json:"-"`
+	// envelopes 生成的响应包装结构. map[response]envelope
+	envelopes map[string]string `json:"-"`
+	// drops query 中被忽略且已警告的字段
+	drops map[string]bool `json:"-"`
+
 	// Swagger version
 	Swagger string `json:"swagger,omitempty"`
 	// Info service info
@@ -62,11 +112,34 @@ type Swagger struct {
 	// Schemes scheme HTTP and HTTPS
 	Schemes []string `json:"schemes,omitempty"`
 	// Paths api list. map[uri][method]*API
-	Paths map[string]map[string]*API `json:"paths,omitempty"`
+	Paths *Paths `json:"paths,omitempty"`
 	// Definitions model list
 	Definitions map[string]*Definition `json:"definitions,omitempty"`
+	// SecurityDefinitions security schemes
+	SecurityDefinitions map[string]*SecurityScheme `json:"securityDefinitions,omitempty"`
+	// Security global security requirements
+	Security SecurityRequirements `json:"security,omitempty"`
 }
 
+// SecurityScheme security scheme
+type SecurityScheme struct {
+	// Type apiKey, basic, oauth2
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	// Name apiKey name
+	Name string `json:"name,omitempty"`
+	// In apiKey position
+	In string `json:"in,omitempty"`
+	// Flow oauth2 flow
+	Flow             string            `json:"flow,omitempty"`
+	AuthorizationURL string            `json:"authorizationUrl,omitempty"`
+	TokenURL         string            `json:"tokenUrl,omitempty"`
+	Scopes           map[string]string `json:"scopes,omitempty"`
+}
+
+// SecurityRequirements security requirement list. 多个为任选其一
+type SecurityRequirements []map[string][]string
+
 // Info service info
 type Info struct {
 	// Title api title
@@ -98,9 +171,13 @@ type Definition struct {
 	Format string `json:"format,omitempty"`
 
 	// Enum enum keys
-	Enum []string `json:"enum,omitempty"`
+	Enum []interface{} `json:"enum,omitempty"`
 	// Default enum default
-	Default string `json:"default,omitempty"`
+	Default interface{} `json:"default,omitempty"`
+	// EnumVarnames enum names for client generators
+	EnumVarnames []string `json:"x-enum-varnames,omitempty"`
+	// EnumDescriptions enum value descriptions
+	EnumDescriptions []string `json:"x-enum-descriptions,omitempty"`
 
 	// Reflex others Definition point
 	Reflex string `json:"$ref,omitempty"`
@@ -112,11 +189,23 @@ type Definition struct {
 	Entry *Definition `json:"additionalProperties,omitempty"`
 
 	// Nesteds nested
-	Nesteds map[string]*Definition `json:"properties,omitempty"`
+	Nesteds *Properties `json:"properties,omitempty"`
+	// Required required properties
+	Required []string `json:"required,omitempty"`
+	// ReadOnly OUTPUT_ONLY field
+	ReadOnly bool `json:"readOnly,omitempty"`
+	// WriteOnly INPUT_ONLY field. swagger 2.0 不支持 writeOnly
+	WriteOnly bool `json:"x-writeOnly,omitempty"`
+
+	// Example example value
+	Example interface{} `json:"example,omitempty"`
 }
 
 // API .
 type API struct {
+	// rpc service.rpc
+	rpc *protoc.ServiceMethod `json:"-"`
+
 	// Tags tag name list
 	Tags []string `json:"tags,omitempty"`
 	// Summary summary
@@ -131,8 +220,29 @@ type API struct {
 	Produces []string `json:"produces,omitempty"`
 	// Parameters request
 	Parameters []*Parameter `json:"parameters,omitempty"`
-	// Responses response
-	Responses map[string]*Parameter `json:"responses,omitempty"`
+	// Security security requirements. 空列表为不需要认证
+	Security *SecurityRequirements `json:"security,omitempty"`
+	// Responses response. map[code]*Response
+	Responses map[string]*Response `json:"responses,omitempty"`
+}
+
+// Response .
+type Response struct {
+	// Description description
+	Description string `json:"description"`
+	// Schema Definition path
+	Schema *Definition `json:"schema,omitempty"`
+	// Headers response headers
+	Headers map[string]*Header `json:"headers,omitempty"`
+	// Examples map[content-type]example
+	Examples map[string]interface{} `json:"examples,omitempty"`
+}
+
+// Header response header
+type Header struct {
+	Type string `json:"type,omitempty"`
+	// Description description
+	Description string `json:"description,omitempty"`
 }
 
 // Parameter .
@@ -140,15 +250,22 @@ type Parameter struct {
 	In       Position `json:"in,omitempty"`
 	Name     string   `json:"name,omitempty"`
 	Type     string   `json:"type,omitempty"`
+	Format   string   `json:"format,omitempty"`
 	Required bool     `json:"required,omitempty"`
+	// Pattern path segment pattern
+	Pattern string `json:"pattern,omitempty"`
 	// Enum enum keys
-	Enum []string `json:"enum,omitempty"`
+	Enum []interface{} `json:"enum,omitempty"`
 	// Default default value
-	Default string `json:"default,omitempty"`
+	Default interface{} `json:"default,omitempty"`
+	// Example example value. 仅用于非 body 参数, body 参数示例位于 schema 中
+	Example interface{} `json:"x-example,omitempty"`
 	// Description description
 	Description string `json:"description,omitempty"`
 	// Schema Definition path
 	Schema *Definition `json:"schema,omitempty"`
 	// Items array info
 	Items *Definition `json:"items,omitempty"`
+	// CollectionFormat array format. e.g. multi: ids=1&ids=2
+	CollectionFormat string `json:"collectionFormat,omitempty"`
 }
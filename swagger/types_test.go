@@ -0,0 +1,27 @@
+package swagger
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// 非标量类型
+var nonscalars = map[descriptorpb.FieldDescriptorProto_Type]bool{
+	descriptorpb.FieldDescriptorProto_TYPE_ENUM:    true,
+	descriptorpb.FieldDescriptorProto_TYPE_GROUP:   true,
+	descriptorpb.FieldDescriptorProto_TYPE_MESSAGE: true,
+}
+
+// TestPrototypes 所有标量类型必须存在于 prototypes
+func TestPrototypes(t *testing.T) {
+	for number, name := range descriptorpb.FieldDescriptorProto_Type_name {
+		var typ = descriptorpb.FieldDescriptorProto_Type(number)
+		if nonscalars[typ] {
+			continue
+		}
+		if _, found := prototypes[typ]; !found {
+			t.Errorf("scalar type %s is missing in prototypes", name)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package swagger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FormatYAML format=yaml
+const FormatYAML = "yaml"
+
+// toYAML json 转换为 yaml, 保持 key 顺序
+func toYAML(data []byte) ([]byte, error) {
+	var decoder = json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	node, err := yamlNode(decoder)
+	if err != nil {
+		return nil, err
+	}
+
+	var buff = new(bytes.Buffer)
+	var encoder = yaml.NewEncoder(buff)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buff.Bytes(), nil
+}
+
+// yamlNode 读取下一个 json value
+func yamlNode(decoder *json.Decoder) (*yaml.Node, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := token.(type) {
+	case json.Delim:
+		switch v {
+		case '{':
+			var node = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			for decoder.More() {
+				key, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := yamlNode(decoder)
+				if err != nil {
+					return nil, err
+				}
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: fmt.Sprint(key)}, value)
+			}
+			_, err = decoder.Token()
+			return node, err
+		case '[':
+			var node = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+			for decoder.More() {
+				value, err := yamlNode(decoder)
+				if err != nil {
+					return nil, err
+				}
+				node.Content = append(node.Content, value)
+			}
+			_, err = decoder.Token()
+			return node, err
+		default:
+			return nil, fmt.Errorf("unexpected delimiter %s", v)
+		}
+	case string:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v}, nil
+	case json.Number:
+		var tag = "!!int"
+		if _, err := v.Int64(); err != nil {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: v.String()}, nil
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: fmt.Sprint(v)}, nil
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	}
+}